@@ -0,0 +1,113 @@
+package obrc
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// StationResult holds the aggregated measurements of a single station.
+// Min, Max and Sum are in tenths of a degree.
+type StationResult struct {
+	Station  string
+	Min, Max int64
+	Sum      int64
+	Count    int64
+}
+
+// Mean returns the rounded average measurement of the station in tenths of a degree.
+func (r StationResult) Mean() int64 {
+	return MeanTenths(r.Sum, r.Count)
+}
+
+// Results is a collection of station results ordered by station name.
+type Results []StationResult
+
+// Sort orders the results by station name.
+func (rs Results) Sort() {
+	slices.SortFunc(rs, func(a, b StationResult) int {
+		return strings.Compare(a.Station, b.Station)
+	})
+}
+
+// WriteTo writes the results in the canonical 1BRC format, e.g.
+// {Abha=-23.0/18.0/59.2, Abidjan=-16.2/26.0/67.3}, followed by a newline.
+func (rs Results) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+
+	var buf []byte
+	bw.WriteByte('{')
+	for i, r := range rs {
+		if i > 0 {
+			bw.WriteString(", ")
+		}
+		buf = append(buf[:0], r.Station...)
+		buf = append(buf, '=')
+		buf = AppendTenths(buf, r.Min)
+		buf = append(buf, '/')
+		buf = AppendTenths(buf, r.Mean())
+		buf = append(buf, '/')
+		buf = AppendTenths(buf, r.Max)
+		bw.Write(buf)
+	}
+	bw.WriteString("}\n")
+
+	if err := bw.Flush(); err != nil {
+		return cw.n, fmt.Errorf("Failed to write results to output: %v", err)
+	}
+	return cw.n, nil
+}
+
+// String returns the results in the canonical 1BRC format without the trailing newline.
+func (rs Results) String() string {
+	var sb strings.Builder
+	rs.WriteTo(&sb)
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+// Rows returns the total number of measurements aggregated in the results.
+func (rs Results) Rows() int64 {
+	var rows int64
+	for _, r := range rs {
+		rows += r.Count
+	}
+	return rows
+}
+
+// Merge combines partial results, e.g. of separate files or chunks, into sorted results
+// with one entry per station.
+func Merge(parts ...Results) Results {
+	index := make(map[string]int)
+	var merged Results
+	for _, part := range parts {
+		for _, r := range part {
+			i, ok := index[r.Station]
+			if !ok {
+				index[r.Station] = len(merged)
+				merged = append(merged, r)
+				continue
+			}
+			m := &merged[i]
+			m.Min = min(m.Min, r.Min)
+			m.Max = max(m.Max, r.Max)
+			m.Sum += r.Sum
+			m.Count += r.Count
+		}
+	}
+	merged.Sort()
+	return merged
+}
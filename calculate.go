@@ -2,18 +2,64 @@ package obrc
 
 import (
 	"io"
+	"os"
 )
 
 // Calculator is an interface for custom Calculate implementations.
 type Calculator interface {
-	Calculate(inputFile string, output io.Writer) error
+	Calculate(inputFile string) (Results, error)
 }
 
 // CalculateFunc is a function type that implements the Calculator interface.
-type CalculateFunc func(inputFile string, output io.Writer) error
+type CalculateFunc func(inputFile string) (Results, error)
 
 // Calculate calls the CalculateFunc with the provided input,
 // allowing CalculateFunc to be used as a Calculator implementation.
-func (f CalculateFunc) Calculate(inputFile string, output io.Writer) error {
-	return f(inputFile, output)
+func (f CalculateFunc) Calculate(inputFile string) (Results, error) {
+	return f(inputFile)
+}
+
+// StreamCalculator is implemented by Calculators that can read measurements from any io.Reader,
+// such as pipes, decompressors, network streams or in-memory buffers.
+type StreamCalculator interface {
+	CalculateReader(input io.Reader) (Results, error)
+}
+
+// StreamFunc is a function type that implements both the Calculator and StreamCalculator interfaces.
+type StreamFunc func(input io.Reader) (Results, error)
+
+// CalculateReader calls the StreamFunc with the provided input.
+// Read errors other than io.EOF are returned even if the StreamFunc stopped reading quietly.
+func (f StreamFunc) CalculateReader(input io.Reader) (Results, error) {
+	er := &errorReader{r: input}
+	results, err := f(er)
+	if er.err != nil {
+		return nil, er.err
+	}
+	return results, err
+}
+
+// Calculate opens inputFile and streams it through the StreamFunc.
+func (f StreamFunc) Calculate(inputFile string) (Results, error) {
+	file, err := os.Open(inputFile)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return f.CalculateReader(file)
+}
+
+// errorReader remembers the first read error other than io.EOF.
+type errorReader struct {
+	r   io.Reader
+	err error
+}
+
+func (er *errorReader) Read(p []byte) (int, error) {
+	n, err := er.r.Read(p)
+	if err != nil && err != io.EOF && er.err == nil {
+		er.err = err
+	}
+	return n, err
 }
@@ -0,0 +1,33 @@
+package obrc
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestStreamFuncReadError(t *testing.T) {
+	// Like the buffered implementations, stop reading quietly on any error
+	quiet := StreamFunc(func(input io.Reader) (Results, error) {
+		buf := make([]byte, 4)
+		for {
+			if _, err := input.Read(buf); err != nil {
+				return Results{{Station: "Partial", Count: 1}}, nil
+			}
+		}
+	})
+
+	errBroken := errors.New("broken pipe")
+	input := io.MultiReader(strings.NewReader("Abha;18.0\n"), iotest.ErrReader(errBroken))
+	if _, err := quiet.CalculateReader(input); !errors.Is(err, errBroken) {
+		t.Errorf("CalculateReader() error = %v, want %v", err, errBroken)
+	}
+
+	results, err := quiet.CalculateReader(bytes.NewReader(nil))
+	if err != nil || len(results) != 1 {
+		t.Errorf("CalculateReader() = %v, %v; want results and no error at EOF", results, err)
+	}
+}
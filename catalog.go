@@ -0,0 +1,163 @@
+package obrc
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// LoadStationsFile reads a station catalog from a file, see LoadStations for the format.
+func LoadStationsFile(fileName string) ([]WeatherStation, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	stations, err := LoadStations(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", fileName, err)
+	}
+	return stations, nil
+}
+
+// LoadStations reads station name and mean temperature pairs, one per line.
+//
+// Both comma separated CSV ("name,mean", with quoting for names containing commas) and
+// the original 1BRC weather_stations.csv format ("name;mean") are accepted; the separator
+// is detected from the first data line. Lines starting with '#' are comments and an
+// optional header line of column labels, e.g. "name,mean", is skipped. Names must be valid
+// UTF-8 of 1 to 100 bytes.
+func LoadStations(r io.Reader) ([]WeatherStation, error) {
+	br := bufio.NewReader(r)
+
+	cr := csv.NewReader(br)
+	cr.Comma = detectSeparator(br)
+	cr.Comment = '#'
+	cr.FieldsPerRecord = 2
+	cr.LazyQuotes = true
+
+	var stations []WeatherStation
+	for first := true; ; first = false {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+
+		if first && isHeader(record) {
+			continue
+		}
+
+		name := record[0]
+		mean, err := strconv.ParseFloat(strings.TrimSpace(record[1]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid mean temperature %q", line, record[1])
+		}
+		if err := validateStationName(name); err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+
+		stations = append(stations, WeatherStation{ID: name, MeanTemperature: mean})
+	}
+
+	if len(stations) == 0 {
+		return nil, fmt.Errorf("no stations found")
+	}
+	return stations, nil
+}
+
+// Column labels accepted in a header line, compared case-insensitively.
+var (
+	nameLabels = []string{"name", "station", "station_name", "city", "id"}
+	meanLabels = []string{"mean", "mean_temperature", "temperature", "temp", "avg"}
+)
+
+// isHeader reports whether a record is a header line of column labels, e.g. "name,mean".
+func isHeader(record []string) bool {
+	isLabel := func(field string, labels []string) bool {
+		field = strings.TrimSpace(field)
+		for _, label := range labels {
+			if strings.EqualFold(field, label) {
+				return true
+			}
+		}
+		return false
+	}
+	return isLabel(record[0], nameLabels) && isLabel(record[1], meanLabels)
+}
+
+// detectSeparator peeks at the first data line and returns ';' if it contains one outside
+// quotes, otherwise ','.
+func detectSeparator(br *bufio.Reader) rune {
+	for n := 64; ; n *= 2 {
+		peek, err := br.Peek(n)
+		last := err != nil || n >= br.Size()
+		if sep, ok := firstSeparator(peek, last); ok || last {
+			return sep
+		}
+	}
+}
+
+// firstSeparator returns the separator of the first data line in data, skipping comment
+// and blank lines. A line is only classified once it is complete, so ok is false if data
+// ends within the first data line, unless atEOF says that nothing follows data.
+func firstSeparator(data []byte, atEOF bool) (sep rune, ok bool) {
+	var semicolon, comma, quoted, comment bool
+	start := true
+	for _, c := range data {
+		if start && c == '#' {
+			comment = true
+		}
+		start = false
+		switch {
+		case comment:
+			if c == '\n' {
+				comment, start = false, true
+			}
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == ';':
+			semicolon = true
+		case c == ',':
+			comma = true
+		case c == '\n':
+			if semicolon || comma {
+				return separator(semicolon), true
+			}
+			start = true
+		}
+	}
+	return separator(semicolon), atEOF
+}
+
+func separator(semicolon bool) rune {
+	if semicolon {
+		return ';'
+	}
+	return ','
+}
+
+// validateStationName checks a name against the 1BRC rules for station names.
+func validateStationName(name string) error {
+	if err := checkStationNameLength(len(name)); err != nil {
+		return fmt.Errorf("%v, %.20q is %d bytes", err, name, len(name))
+	}
+	switch {
+	case !utf8.ValidString(name):
+		return fmt.Errorf("station name %q is not valid UTF-8", name)
+	case strings.ContainsAny(name, ";\n"):
+		return fmt.Errorf("station name %q contains ';' or a newline", name)
+	}
+	return nil
+}
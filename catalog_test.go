@@ -0,0 +1,86 @@
+package obrc
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadStations(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []WeatherStation
+		wantErr bool
+	}{
+		{
+			name:  "1brc format with comments",
+			input: "# Adapted from https://simplemaps.com/data/world-cities\n# Licensed under CC BY 4.0\nTokyo;35.6897\nJakarta;-6.1750\n",
+			want:  []WeatherStation{{"Tokyo", 35.6897}, {"Jakarta", -6.175}},
+		},
+		{
+			name:  "csv with header and quoted name",
+			input: "name,mean\n\"Washington, D.C.\",14.6\nZürich,9.3\n",
+			want:  []WeatherStation{{"Washington, D.C.", 14.6}, {"Zürich", 9.3}},
+		},
+		{
+			name:  "no trailing newline",
+			input: "Abha;18.0",
+			want:  []WeatherStation{{"Abha", 18.0}},
+		},
+		{
+			name:  "1brc format with header",
+			input: "station;mean\nAbha;18.0\n",
+			want:  []WeatherStation{{"Abha", 18.0}},
+		},
+		{
+			// The first line is longer than the first peek and has a ',' before the ';'
+			name:  "long first line",
+			input: "Foo, " + strings.Repeat("x", 70) + ";1.0\n",
+			want:  []WeatherStation{{"Foo, " + strings.Repeat("x", 70), 1.0}},
+		},
+		{name: "empty", input: "", wantErr: true},
+		{name: "invalid first mean", input: "Accra;warm\nAbha;1.0\n", wantErr: true},
+		{name: "only an invalid row", input: "Accra;warm\n", wantErr: true},
+		{name: "missing mean", input: "Abha;18.0\nAccra\n", wantErr: true},
+		{name: "invalid mean", input: "Abha;18.0\nAccra;warm\n", wantErr: true},
+		{name: "semicolon in name", input: "name,mean\nAbha,18.0\n\"Ab;ha\",1.0\n", wantErr: true},
+		{name: "newline in name", input: "\"Ab\nha\",18.0\n", wantErr: true},
+		{name: "empty name", input: "Abha;18.0\n;1.0\n", wantErr: true},
+		{name: "name too long", input: strings.Repeat("x", 101) + ";1.0\n", wantErr: true},
+		{name: "invalid utf-8", input: "Ab\xffha;1.0\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LoadStations(strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadStations() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("LoadStations() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("LoadStations()[%d] = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLoadStationsErrorLine(t *testing.T) {
+	tests := []struct {
+		input   string
+		wantErr string
+	}{
+		{"Accra;warm\nAbha;1.0\n", `line 1: invalid mean temperature "warm"`},
+		{"name,mean\nAbha,18.0\n\"Ab;ha\",1.0\n", `line 3: station name "Ab;ha" contains ';' or a newline`},
+		// The quoted ';' must not be taken for the separator
+		{"\"Ab;ha\",18.0\n", `line 1: station name "Ab;ha" contains ';' or a newline`},
+	}
+	for _, tt := range tests {
+		_, err := LoadStations(strings.NewReader(tt.input))
+		if err == nil || err.Error() != tt.wantErr {
+			t.Errorf("LoadStations(%q) error = %v, want %s", tt.input, err, tt.wantErr)
+		}
+	}
+}
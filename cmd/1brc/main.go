@@ -1,26 +1,25 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"io"
+	"math/rand"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"runtime"
 	"runtime/pprof"
 	"runtime/trace"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	obrc "github.com/tyleryarnell/1brc"
-	"github.com/tyleryarnell/1brc/internal/baseline"
-	one "github.com/tyleryarnell/1brc/internal/r01"
-	two "github.com/tyleryarnell/1brc/internal/r02"
-	three "github.com/tyleryarnell/1brc/internal/r03"
-	four "github.com/tyleryarnell/1brc/internal/r04"
-	five "github.com/tyleryarnell/1brc/internal/r05"
-	six "github.com/tyleryarnell/1brc/internal/r06"
-	seven "github.com/tyleryarnell/1brc/internal/r07"
-	eight "github.com/tyleryarnell/1brc/internal/r08"
+	_ "github.com/tyleryarnell/1brc/internal/columnar"
+	_ "github.com/tyleryarnell/1brc/internal/versions"
 )
 
 const usageMessage = `
@@ -29,13 +28,58 @@ Usage: %[1]s <command> [options]
 Commands:
   create  Create measurements and save them to a file.
   run     Run the calculation using either the default (baseline) or a custom implementation.
+  list    List the available implementations.
+  bench   Time every implementation (or those selected with -version) on a file.
+  reference  Compute the expected results for a measurements file with the baseline implementation.
+  verify  Compare a results file against the expected results station by station.
   graph   Generate a graph based on previous runs.
 
 Examples:
   Create Measurements:
     %[1]s create -size=5000000 -file="output.txt"
+  Create Reproducible Measurements:
+    %[1]s create -size=5000000 -seed=42 -file="output.txt"
+  Create Measurements for 10,000 Synthetic Stations:
+    %[1]s create -size=5000000 -stations=10000 -name-lengths="uniform:1-100" -file="output.txt"
+  Create Measurements for Stations Listed in a File (name;mean or name,mean per line):
+    %[1]s create -size=5000000 -stations-file="weather_stations.csv" -file="output.txt"
+  Create Measurements with Zipf Distributed Station Popularity:
+    %[1]s create -size=5000000 -distribution=zipf -zipf-s=1.2 -file="output.txt"
+  Create Compressed Measurements (gzip or zstd):
+    %[1]s create -size=5000000 -compress=zstd -file="output.txt.zst"
+  Create Measurements with One Goroutine (the same file as with any -workers):
+    %[1]s create -size=5000000 -seed=42 -workers=1 -file="output.txt"
   Run Baseline Calculation:
     %[1]s run -version=0 -file="output.txt" -tracefile="trace.out" -cpuprofile="cpu.prof" -save-results -save-metrics -validate="expected.txt"
+  Run an Implementation by Name:
+    %[1]s run -version=r08 -file="output.txt"
+  Run a Streaming Implementation on Standard Input:
+    cat output.txt | %[1]s run -version=r07 -file=-
+  Run a Streaming Implementation on a Compressed File (detected from its contents):
+    %[1]s run -version=r07 -file="output.txt.zst"
+  Run on Every File Matching a Pattern or in a Directory (results are merged, rows are reported per file):
+    %[1]s run -version=r07 -file="data/*.txt"
+    %[1]s run -version=r07 -file="data"
+  Run the Work-Stealing Implementation (reports segments and idle time per worker):
+    %[1]s run -version=r09 -file="output.txt"
+  Run With Machine-Readable Output (json, ndjson, csv or tsv; status messages go to stderr):
+    %[1]s run -version=r10 -file="output.txt" -format=json > results.json
+    %[1]s run -version=r10 -file="output.txt" -format=csv -tenths -save-results
+  Export Results as an Apache Arrow IPC or Parquet File (saved as runs/<size>/<timestamp>/results.arrow or .parquet):
+    %[1]s run -version=r10 -file="output.txt" -format=parquet -save-results
+    %[1]s run -version=r10 -file="output.txt" -format=arrow > results.arrow
+  Run With Input Validation (-strict stops at the first malformed row, -lenient skips and counts them,
+  which works with implementations tagged "stream" or "lenient"):
+    %[1]s run -version=r07 -file="output.txt" -strict
+    %[1]s run -version=r07 -file="output.txt" -lenient
+    %[1]s run -version=r10 -file="output.txt" -lenient
+  Run With a Timeout (Ctrl-C also stops the run and still writes the trace and profile):
+    %[1]s run -version=r08 -file="output.txt" -timeout=30s -cpuprofile="cpu.prof"
+  Benchmark Selected Implementations:
+    %[1]s bench -version=r07,r08 -file="output.txt"
+  Generate Reference Results and Verify Against Them:
+    %[1]s reference -file="output.txt" -out="expected.txt"
+    %[1]s verify -expected="expected.txt" -actual="runs/txt/20240101_120000/results.txt"
   Generate Graph:
     %[1]s graph
 `
@@ -47,14 +91,34 @@ func main() {
 	}
 
 	// Parse common flags
-	fileName := flag.String("file", "measurements.txt", "File name to read measurements")
-	version := flag.Int("version", 0, "Version of the calculation to use")
+	fileName := flag.String("file", "measurements.txt", "File name, directory or glob pattern to read measurements from (- reads stdin)")
+	version := flag.String("version", "", "Name or number of the implementation to use (comma separated for bench)")
 	traceFile := flag.String("tracefile", "", "Enable execution tracing and save to the specified file")
 	cpuProfileFile := flag.String("cpuprofile", "", "Enable CPU profiling and save to the specified file")
 	saveResults := flag.Bool("save-results", false, "Save calculation results to a file")
 	saveMetrics := flag.Bool("save-metrics", false, "Save time metrics to a file")
 	validateFile := flag.String("validate", "", "Validate calculation results against the specified file")
+	format := flag.String("format", "text", "Output format of results: "+strings.Join(obrc.FormatNames(), ", "))
+	tenths := flag.Bool("tenths", false, "Write min, mean and max as integer tenths of a degree (json, ndjson, csv and tsv)")
+	strict := flag.Bool("strict", false, "Validate every row and stop with an error at the first malformed one")
+	lenient := flag.Bool("lenient", false, "Validate every row and skip malformed ones, reporting how many were skipped (implementations tagged stream or lenient)")
+	timeout := flag.Duration("timeout", 0, "Stop the calculation after this long, e.g. 30s (0 means no timeout)")
 	size := flag.Int("size", 10000000, "Number of records to create")
+	seed := flag.Int64("seed", 0, "Seed for creating measurements (0 picks a random seed)")
+	workers := flag.Int("workers", 8, "Number of goroutines creating measurements (output depends only on the seed)")
+	numStations := flag.Int("stations", 0, "Number of synthetic stations to create measurements for (0 uses the built-in stations)")
+	stationsFile := flag.String("stations-file", "", "CSV file of station names and mean temperatures to create measurements for")
+	distribution := flag.String("distribution", "normal", "Measurement distribution: normal, uniform, zipf or seasonal")
+	stddev := flag.Float64("stddev", 10, "Standard deviation of measurements around the station mean")
+	clamp := flag.Bool("clamp", true, "Clamp measurements to the spec range [-99.9, 99.9]")
+	zipfS := flag.Float64("zipf-s", 1.0, "Exponent of the zipf station popularity distribution")
+	seasonAmplitude := flag.Float64("season-amplitude", 10, "Amplitude of the seasonal distribution")
+	seasonPeriod := flag.Int("season-period", 0, "Rows per cycle of the seasonal distribution (default the whole file)")
+	compress := flag.String("compress", "none", "Compress created measurements: gzip, zstd or none")
+	nameLengths := flag.String("name-lengths", "uniform:1-100", "Byte length distribution of synthetic station names: uniform:MIN-MAX or normal:MEAN,STDDEV")
+	outFile := flag.String("out", "", "File to write reference results to (default stdout)")
+	expectedFile := flag.String("expected", "", "Expected results file for verify")
+	actualFile := flag.String("actual", "", "Actual results file for verify")
 
 	// First, we need to check the command, which comes before the flags.
 	command := os.Args[1]
@@ -64,9 +128,34 @@ func main() {
 
 	switch command {
 	case "create":
-		createMeasurements(*size, *fileName)
+		dist := distributionOptions{
+			name:      *distribution,
+			stddev:    *stddev,
+			clamp:     *clamp,
+			zipfS:     *zipfS,
+			amplitude: *seasonAmplitude,
+			period:    *seasonPeriod,
+		}
+		createMeasurements(*size, *seed, *workers, *numStations, *stationsFile, *nameLengths, dist, *compress, *fileName)
 	case "run":
-		handleRunCommand(*fileName, *version, *traceFile, *cpuProfileFile, *saveResults, *saveMetrics, *validateFile)
+		if err := handleRunCommand(*fileName, *version, *traceFile, *cpuProfileFile, *saveResults, *saveMetrics, *validateFile, *format, *tenths, *strict, *lenient, *timeout); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	case "list":
+		handleListCommand()
+	case "bench":
+		handleBenchCommand(*fileName, *version)
+	case "reference":
+		if err := handleReferenceCommand(*fileName, *outFile); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	case "verify":
+		if err := handleVerifyCommand(*expectedFile, *actualFile); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
 	case "graph":
 		handleGraphCommand()
 	default:
@@ -77,7 +166,7 @@ func main() {
 }
 
 // handleRunCommand processes the "run" command with optional tracing, CPU profiling, conditional result saving, and validation.
-func handleRunCommand(fileName string, version int, traceFile string, cpuProfileFile string, saveResults bool, saveMetrics bool, validateFile string) {
+func handleRunCommand(fileName string, version string, traceFile string, cpuProfileFile string, saveResults bool, saveMetrics bool, validateFile string, format string, tenths bool, strict bool, lenient bool, timeout time.Duration) error {
 	// Start tracing if specified
 	if traceFile != "" {
 		f, err := os.Create(traceFile)
@@ -110,7 +199,99 @@ func handleRunCommand(fileName string, version int, traceFile string, cpuProfile
 		defer pprof.StopCPUProfile()
 	}
 
-	runCalculation(fileName, version, saveResults, saveMetrics, validateFile)
+	// Stop the calculation on Ctrl-C or when the timeout expires. The calculation returns
+	// an error instead of exiting, so the deferred trace and profile shutdown above still runs.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	return runCalculation(ctx, fileName, version, saveResults, saveMetrics, validateFile, format, tenths, strict, lenient)
+}
+
+// handleListCommand processes the "list" command.
+func handleListCommand() {
+	for _, impl := range obrc.Implementations() {
+		fmt.Printf("%2d  %-10s %-34s [%s]\n", impl.Version, impl.Name, impl.Description, strings.Join(impl.Tags, ", "))
+	}
+}
+
+// handleBenchCommand processes the "bench" command, timing each selected implementation on the same file.
+func handleBenchCommand(fileName string, versions string) {
+	var impls []obrc.Implementation
+	if versions == "" {
+		impls = obrc.Implementations()
+	} else {
+		for _, key := range strings.Split(versions, ",") {
+			impl, ok := obrc.Lookup(strings.TrimSpace(key))
+			if !ok {
+				fmt.Printf("Unknown implementation: %s\n", key)
+				os.Exit(1)
+			}
+			impls = append(impls, impl)
+		}
+	}
+
+	for _, impl := range impls {
+		start := time.Now()
+		if _, _, err := calculateInputs(context.Background(), impl, fileName, nil); err != nil {
+			fmt.Printf("%-10s error: %v\n", impl.Name, err)
+			continue
+		}
+		fmt.Printf("%-10s %v\n", impl.Name, time.Since(start))
+	}
+}
+
+// handleReferenceCommand processes the "reference" command, writing the baseline results for a measurements file.
+func handleReferenceCommand(fileName string, outFile string) error {
+	impl, ok := obrc.Lookup("baseline")
+	if !ok {
+		return fmt.Errorf("baseline implementation is not registered")
+	}
+
+	results, _, err := calculateInputs(context.Background(), impl, fileName, nil)
+	if err != nil {
+		return fmt.Errorf("computing reference results: %v", err)
+	}
+
+	if outFile == "" {
+		_, err = results.WriteTo(os.Stdout)
+		return err
+	}
+
+	f, err := os.Create(outFile)
+	if err != nil {
+		return err
+	}
+	if _, err := results.WriteTo(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Printf("Reference results for %d stations saved to '%s'.\n", len(results), outFile)
+	return nil
+}
+
+// handleVerifyCommand processes the "verify" command, comparing two results files.
+func handleVerifyCommand(expectedFile string, actualFile string) error {
+	if expectedFile == "" || actualFile == "" {
+		return fmt.Errorf("verify requires both -expected and -actual")
+	}
+
+	expected, err := readRecords(expectedFile)
+	if err != nil {
+		return err
+	}
+	actual, err := readRecords(actualFile)
+	if err != nil {
+		return err
+	}
+	return reportDiff(os.Stdout, obrc.Compare(expected, actual))
 }
 
 // handleGraphCommand processes the "graph" command.
@@ -119,11 +300,52 @@ func handleGraphCommand() {
 }
 
 // createMeasurements generates a set of measurements and saves them to a file.
-func createMeasurements(size int, fileName string) {
-	fmt.Printf("Creating %d measurements...\n", size)
+func createMeasurements(size int, seed int64, workers int, numStations int, stationsFile string, nameLengths string, distOpts distributionOptions, compress string, fileName string) {
+	compression, err := obrc.ParseCompression(compress)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
 
-	// Write the measurements to the specified file
-	if err := obrc.WriteMeasurements(fileName, size); err != nil {
+	stations := obrc.Stations
+	switch {
+	case numStations > 0 && stationsFile != "":
+		fmt.Println("Error: use either -stations or -stations-file, not both")
+		return
+	case stationsFile != "":
+		var err error
+		stations, err = obrc.LoadStationsFile(stationsFile)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+	case numStations > 0:
+		lengths, err := obrc.ParseNameLengths(nameLengths)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		stations = obrc.SyntheticStations(numStations, lengths, rand.New(rand.NewSource(seed)))
+	}
+
+	dist, err := distOpts.distribution(len(stations), size)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	fmt.Printf("Creating %d measurements for %d stations with %s distribution, seed %d, %d workers and %s compression...\n",
+		size, len(stations), distOpts.name, seed, workers, compression)
+
+	// Write the measurements to the specified file, compressed files are written as a stream
+	if compression == obrc.Uncompressed {
+		err = obrc.WriteMeasurementsParallel(fileName, size, stations, dist, seed, workers)
+	} else {
+		err = obrc.WriteMeasurementsCompressed(fileName, compression, size, stations, dist, seed, workers)
+	}
+	if err != nil {
 		fmt.Printf("Error: %v\n", err)
 		return
 	}
@@ -131,47 +353,74 @@ func createMeasurements(size int, fileName string) {
 	fmt.Println("Measurements created successfully.")
 }
 
-// runCalculation performs the calculation, conditionally saving results, saving time metrics, and optionally validating the output.
-func runCalculation(fileName string, version int, saveResults bool, saveMetrics bool, validateFile string) {
-	var calculator obrc.Calculator
-
-	switch version {
-	case 1:
-		calculator = obrc.CalculateFunc(one.Calculate)
-		fmt.Println("Using iterator implementation...")
-	case 2:
-		calculator = obrc.CalculateFunc(two.Calculate)
-		fmt.Println("Using buffered reader implementation...")
-	case 3:
-		calculator = obrc.CalculateFunc(three.Calculate)
-		fmt.Println("Using map assigns implementation...")
-	case 4:
-		calculator = obrc.CalculateFunc(four.Calculate)
-		fmt.Println("Using parse as bytes implementation...")
-	case 5:
-		calculator = obrc.CalculateFunc(five.Calculate)
-		fmt.Println("Using improved bytes parsing implementation...")
-	case 6:
-		calculator = obrc.CalculateFunc(six.Calculate)
-		fmt.Println("Using byte parsing and int conversion implementation...")
-	case 7:
-		calculator = obrc.CalculateFunc(seven.Calculate)
-		fmt.Println("Using custom hash table implementation...")
-	case 8:
-		calculator = obrc.CalculateFunc(eight.Calculate)
-		fmt.Println("Using parallel file chunking implementation...")
+// distributionOptions holds the create flags that select the measurement distribution.
+type distributionOptions struct {
+	name      string
+	stddev    float64
+	clamp     bool
+	zipfS     float64
+	amplitude float64
+	period    int
+}
+
+// distribution builds the selected distribution for a file of size rows over nStations stations.
+func (o distributionOptions) distribution(nStations int, size int) (obrc.Distribution, error) {
+	switch o.name {
+	case "normal":
+		return obrc.Normal{StdDev: o.stddev, Clamp: o.clamp}, nil
+	case "uniform":
+		return obrc.Uniform{StdDev: o.stddev, Clamp: o.clamp}, nil
+	case "zipf":
+		return obrc.NewZipf(o.zipfS, nStations, o.stddev, o.clamp), nil
+	case "seasonal":
+		period := o.period
+		if period <= 0 {
+			period = size
+		}
+		return obrc.Seasonal{Amplitude: o.amplitude, Period: period, StdDev: o.stddev, Clamp: o.clamp}, nil
 	default:
-		calculator = obrc.CalculateFunc(baseline.Calculate)
-		fmt.Println("Using the default (baseline) implementation...")
+		return nil, fmt.Errorf("unknown distribution %q", o.name)
+	}
+}
+
+// runCalculation performs the calculation, conditionally saving results, saving time metrics, and optionally validating the output.
+func runCalculation(ctx context.Context, fileName string, version string, saveResults bool, saveMetrics bool, validateFile string, format string, tenths bool, strict bool, lenient bool) error {
+	outputFormat, ok := obrc.LookupFormat(format)
+	if !ok {
+		return fmt.Errorf("unknown format %q, want one of %s", format, strings.Join(obrc.FormatNames(), ", "))
+	}
+
+	// Keep status messages out of machine-readable results printed to stdout
+	var info io.Writer = os.Stdout
+	if !saveResults && outputFormat.Name != "text" {
+		info = os.Stderr
+	}
+
+	var validation *rowValidation
+	switch {
+	case strict && lenient:
+		return fmt.Errorf("use either -strict or -lenient, not both")
+	case strict:
+		validation = &rowValidation{mode: obrc.Strict}
+	case lenient:
+		validation = &rowValidation{mode: obrc.Lenient}
 	}
 
+	if version == "" {
+		version = "baseline"
+	}
+	impl, ok := obrc.Lookup(version)
+	if !ok {
+		return fmt.Errorf("unknown implementation: %s (see the list command)", version)
+	}
+	fmt.Fprintf(info, "Using %s implementation (%s)...\n", impl.Description, impl.Name)
+
 	// Get measurements size for directory creation, e.g., "measurements.1b.txt" -> "1b"
-	dataSize := strings.Split(fileName, ".")[1]
+	dataSize := dataSizeOf(fileName)
 
 	// Create the base runs directory if it doesn't exist
 	if err := os.MkdirAll("runs", 0755); err != nil {
-		fmt.Printf("Failed to create runs directory: %v\n", err)
-		return
+		return fmt.Errorf("failed to create runs directory: %v", err)
 	}
 
 	// Generate a timestamp and create a directory for this run under the dataSize directory
@@ -179,82 +428,264 @@ func runCalculation(fileName string, version int, saveResults bool, saveMetrics
 	runDir := filepath.Join("runs", dataSize, timestamp)
 
 	if err := os.MkdirAll(runDir, 0755); err != nil {
-		fmt.Printf("Failed to create run directory: %v\n", err)
-		return
+		return fmt.Errorf("failed to create run directory: %v", err)
 	}
 
 	var outputFile *os.File
 	if saveResults {
 		// Create output file in the run directory
-		outputFileName := filepath.Join(runDir, "results.txt")
+		outputFileName := filepath.Join(runDir, "results"+outputFormat.Extension)
 		var err error
 		outputFile, err = os.Create(outputFileName)
 		if err != nil {
-			fmt.Printf("Failed to create output file: %v\n", err)
-			return
+			return fmt.Errorf("failed to create output file: %v", err)
 		}
 		defer outputFile.Close()
 	} else {
-		// Discard output if not saving results
+		// Print the results if not saving them
 		outputFile = os.Stdout
 	}
 
+	// Collect the worker statistics of parallel implementations that report them
+	var workerStats []obrc.WorkerStats
+	var workerStatsMu sync.Mutex
+	ctx = obrc.WithWorkerStats(ctx, func(stats []obrc.WorkerStats) {
+		workerStatsMu.Lock()
+		defer workerStatsMu.Unlock()
+		workerStats = addWorkerStats(workerStats, stats)
+	})
+
 	// Measure time taken and run the calculation
 	start := time.Now()
-	if err := calculator.Calculate(fileName, outputFile); err != nil {
-		fmt.Printf("Error running calculation: %v\n", err)
-		return
+	results, fileResults, err := calculateInputs(ctx, impl, fileName, validation)
+	if err != nil {
+		return fmt.Errorf("running calculation: %v", err)
 	}
 	duration := time.Since(start)
-	fmt.Printf("Calculation completed in %v\n", duration)
+
+	if err := outputFormat.Write(outputFile, results, obrc.FormatOptions{Tenths: tenths}); err != nil {
+		return fmt.Errorf("writing results: %v", err)
+	}
+	if fileResults != nil {
+		for _, fr := range fileResults {
+			fmt.Fprintf(info, "%s: %d rows\n", fr.File, fr.Rows())
+		}
+		fmt.Fprintf(info, "%d files, %d rows\n", len(fileResults), results.Rows())
+	}
+	if validation != nil && validation.mode == obrc.Lenient {
+		fmt.Fprintf(info, "Skipped %d malformed rows\n", validation.skipped.Load())
+	}
+	for _, st := range workerStats {
+		fmt.Fprintf(info, "worker %2d: %4d segments, %8.1f MB, busy %v, idle %v\n",
+			st.Worker, st.Segments, float64(st.Bytes)/(1024*1024), st.Busy.Round(time.Microsecond), st.Idle.Round(time.Microsecond))
+	}
+	fmt.Fprintf(info, "Calculation completed in %v\n", duration)
 
 	// Save the time metrics if requested
 	if saveMetrics {
-		saveTimeMetrics(duration, runDir)
+		saveTimeMetrics(info, duration, runDir)
 	}
 
 	// Validate output if validation file is specified
 	if validateFile != "" {
-		fmt.Println("Validating results...")
-		validateResults(validateFile, outputFile)
+		fmt.Fprintln(info, "Validating results...")
+		return validateResults(info, validateFile, results)
 	}
+	return nil
 }
 
-// validateResults compares the results of the calculation with a saved results file.
-func validateResults(validateFile string, outputFile *os.File) {
-	expectedBytes, err := os.ReadFile(validateFile)
+// rowValidation holds the -strict or -lenient mode of a run and counts the malformed rows
+// skipped across all of its input files.
+type rowValidation struct {
+	mode    obrc.Validation
+	skipped atomic.Int64
+}
+
+// calculateInputs runs the implementation on every file named by fileName, which may be a directory
+// or glob pattern, and merges their results. Files are processed concurrently, one per core, and the
+// per-file results are returned unless fileName names a single file or stdin. Rows are validated
+// unless validation is nil.
+func calculateInputs(ctx context.Context, impl obrc.Implementation, fileName string, validation *rowValidation) (obrc.Results, []obrc.FileResult, error) {
+	if fileName == "-" {
+		results, err := calculate(ctx, impl, fileName, validation)
+		return results, nil, err
+	}
+
+	files, err := obrc.ExpandInputs(fileName)
 	if err != nil {
-		fmt.Printf("Failed to read validation file: %v\n", err)
-		return
+		return nil, nil, err
+	}
+	if len(files) == 1 && files[0] == fileName {
+		results, err := calculate(ctx, impl, fileName, validation)
+		return results, nil, err
+	}
+
+	return obrc.CalculateFiles(ctx, files, runtime.GOMAXPROCS(0), func(ctx context.Context, file string) (obrc.Results, error) {
+		return calculate(ctx, impl, file, validation)
+	})
+}
+
+// calculate runs the implementation on fileName until ctx is done, streaming stdin when fileName is "-".
+// Gzip and zstd compressed input is detected from its magic bytes and decompressed on the fly.
+func calculate(ctx context.Context, impl obrc.Implementation, fileName string, validation *rowValidation) (obrc.Results, error) {
+	if fileName == "-" {
+		return calculateReader(ctx, impl, fileName, os.Stdin, validation)
 	}
 
-	fmt.Println("Reading output file for validation...")
-	outputBytes, err := io.ReadAll(outputFile)
+	f, err := os.Open(fileName)
 	if err != nil {
-		fmt.Printf("Failed to read output file: %v\n", err)
-		return
+		return nil, err
 	}
+	defer f.Close()
+
+	compression, err := obrc.DetectFileCompression(f)
+	switch {
+	case err != nil:
+		return nil, err
+	case compression != obrc.Uncompressed:
+		return calculateReader(ctx, impl, fileName, f, validation)
+	case validation != nil:
+		return calculateValidated(ctx, impl, fileName, f, compression, validation)
+	default:
+		return obrc.CalculateContext(ctx, impl.Calculator, fileName)
+	}
+}
 
-	fmt.Println("Comparing output with expected results...")
-	if string(expectedBytes) == string(outputBytes) {
-		fmt.Println("Validation successful: output matches the expected results.")
-	} else {
-		fmt.Println("Validation failed: output does not match the expected results.")
+// calculateReader runs the implementation on r, the content of fileName, decompressing it
+// if it is compressed.
+func calculateReader(ctx context.Context, impl obrc.Implementation, fileName string, r io.Reader, validation *rowValidation) (obrc.Results, error) {
+	dr, compression, err := obrc.NewDecompressor(r)
+	if err != nil {
+		return nil, err
+	}
+	defer dr.Close()
+
+	if validation != nil {
+		return calculateValidated(ctx, impl, fileName, dr, compression, validation)
+	}
+	return calculateStream(ctx, impl, fileName, dr, compression)
+}
+
+// calculateValidated runs the implementation on the valid rows of r, the decompressed content of
+// fileName. Implementations that read the file themselves skip malformed rows on their own in
+// lenient mode if they are tagged "lenient", and get the file after a validation pass in strict mode.
+func calculateValidated(ctx context.Context, impl obrc.Implementation, fileName string, r io.Reader, compression obrc.Compression, validation *rowValidation) (obrc.Results, error) {
+	_, stream := impl.Calculator.(obrc.StreamCalculator)
+	readsFile := !stream && compression == obrc.Uncompressed && fileName != "-"
+
+	switch {
+	case readsFile && validation.mode == obrc.Lenient && impl.HasTag("lenient"):
+		ctx = obrc.WithSkipMalformed(ctx, func(skipped int64) { validation.skipped.Add(skipped) })
+		return obrc.CalculateContext(ctx, impl.Calculator, fileName)
+	case readsFile && validation.mode == obrc.Lenient:
+		return nil, fmt.Errorf("%s reads %s itself and cannot skip malformed rows, use an implementation tagged \"stream\" or \"lenient\"", impl.Name, fileName)
+	}
+
+	vr := obrc.NewValidatingReader(r, validation.mode)
+	defer func() { validation.skipped.Add(vr.Skipped()) }()
+	if !readsFile {
+		return calculateStream(ctx, impl, fileName, vr, compression)
+	}
+
+	// Strict mode: fail with the line number of the first malformed row before calculating
+	if _, err := io.Copy(io.Discard, obrc.NewContextReader(ctx, vr)); err != nil {
+		return nil, err
+	}
+	return obrc.CalculateContext(ctx, impl.Calculator, fileName)
+}
+
+// calculateStream streams r, the decompressed content of fileName, through the implementation,
+// or explains why the implementation cannot read it.
+func calculateStream(ctx context.Context, impl obrc.Implementation, fileName string, r io.Reader, compression obrc.Compression) (obrc.Results, error) {
+	sc, ok := impl.Calculator.(obrc.StreamCalculator)
+	switch {
+	case ok:
+		return obrc.CalculateReaderContext(ctx, sc, r)
+	case fileName == "-":
+		return nil, fmt.Errorf("%s cannot read from stdin, use an implementation tagged \"stream\"", impl.Name)
+	case impl.HasTag("mmap"):
+		return nil, fmt.Errorf("%s memory-maps its input and cannot read %s compressed files, use an implementation tagged \"stream\"", impl.Name, compression)
+	default:
+		return nil, fmt.Errorf("%s cannot read %s compressed files, use an implementation tagged \"stream\"", impl.Name, compression)
+	}
+}
+
+// addWorkerStats adds the statistics of a calculation to those of earlier calculations,
+// e.g. of other files, worker by worker.
+func addWorkerStats(total []obrc.WorkerStats, stats []obrc.WorkerStats) []obrc.WorkerStats {
+	for _, st := range stats {
+		for len(total) <= st.Worker {
+			total = append(total, obrc.WorkerStats{Worker: len(total)})
+		}
+		t := &total[st.Worker]
+		t.Segments += st.Segments
+		t.Bytes += st.Bytes
+		t.Busy += st.Busy
+		t.Idle += st.Idle
+	}
+	return total
+}
+
+// dataSizeOf returns the size label of a measurements file, e.g. "measurements.1b.txt" -> "1b".
+func dataSizeOf(fileName string) string {
+	if fileName == "-" {
+		return "stdin"
+	}
+	parts := strings.Split(filepath.Base(fileName), ".")
+	if len(parts) < 2 {
+		return parts[0]
+	}
+	return parts[1]
+}
+
+// validateResults compares the results of the calculation with a saved results file station by station.
+func validateResults(w io.Writer, validateFile string, results obrc.Results) error {
+	expected, err := readRecords(validateFile)
+	if err != nil {
+		return err
+	}
+	return reportDiff(w, obrc.Compare(expected, results.Records()))
+}
+
+// readRecords parses a results file written in the canonical 1BRC format.
+func readRecords(fileName string) ([]obrc.Record, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	records, err := obrc.ParseRecords(f)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %v", fileName, err)
+	}
+	return records, nil
+}
+
+// reportDiff prints the outcome of a comparison to w and returns an error if the results differ.
+func reportDiff(w io.Writer, diff obrc.Diff) error {
+	if diff.OK() {
+		fmt.Fprintln(w, "Validation successful: output matches the expected results.")
+		return nil
 	}
+	fmt.Fprintln(w, diff)
+	return fmt.Errorf("validation failed: %d missing, %d extra, %d mismatched values",
+		len(diff.Missing), len(diff.Extra), len(diff.Mismatches))
 }
 
-// saveTimeMetrics saves the time taken for calculation to a file if the flag is enabled.
-func saveTimeMetrics(duration time.Duration, runDir string) {
+// saveTimeMetrics saves the time taken for calculation to a file if the flag is enabled,
+// reporting to w.
+func saveTimeMetrics(w io.Writer, duration time.Duration, runDir string) {
 	metricsFileName := filepath.Join(runDir, "time_metrics.txt")
 	metricsFile, err := os.Create(metricsFileName)
 	if err != nil {
-		fmt.Printf("Failed to create metrics file: %v\n", err)
+		fmt.Fprintf(w, "Failed to create metrics file: %v\n", err)
 		return
 	}
 	defer metricsFile.Close()
 
 	if _, err := metricsFile.WriteString(fmt.Sprintf("Time taken: %v ms\n", duration.Milliseconds())); err != nil {
-		fmt.Printf("Failed to write time metrics to file: %v\n", err)
+		fmt.Fprintf(w, "Failed to write time metrics to file: %v\n", err)
 	}
-	fmt.Printf("Time metrics saved to '%s'.\n", metricsFileName)
+	fmt.Fprintf(w, "Time metrics saved to '%s'.\n", metricsFileName)
 }
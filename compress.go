@@ -0,0 +1,155 @@
+package obrc
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression identifies how a measurements file is compressed.
+type Compression string
+
+// Supported compressions, Uncompressed is the zero value.
+const (
+	Uncompressed Compression = ""
+	Gzip         Compression = "gzip"
+	Zstd         Compression = "zstd"
+)
+
+// Magic bytes at the start of gzip and zstd streams.
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// ParseCompression parses a compression name: "gzip", "zstd", or "" or "none" for uncompressed.
+func ParseCompression(name string) (Compression, error) {
+	switch name {
+	case "", "none":
+		return Uncompressed, nil
+	case "gzip", "gz":
+		return Gzip, nil
+	case "zstd", "zst":
+		return Zstd, nil
+	default:
+		return Uncompressed, fmt.Errorf("unknown compression %q, want gzip, zstd or none", name)
+	}
+}
+
+// String returns the name of the compression, "none" for uncompressed.
+func (c Compression) String() string {
+	if c == Uncompressed {
+		return "none"
+	}
+	return string(c)
+}
+
+// DetectCompression peeks at the magic bytes at the start of br without consuming them.
+// Input too short to hold a magic number is uncompressed.
+func DetectCompression(br *bufio.Reader) (Compression, error) {
+	peek, err := br.Peek(len(zstdMagic))
+	if err != nil && err != io.EOF {
+		return Uncompressed, err
+	}
+	return compressionOf(peek), nil
+}
+
+// DetectFileCompression reads the magic bytes at the start of f, without moving its offset,
+// so that uncompressed files can be handed on without a buffered reader.
+func DetectFileCompression(f io.ReaderAt) (Compression, error) {
+	magic := make([]byte, len(zstdMagic))
+	n, err := f.ReadAt(magic, 0)
+	if err != nil && err != io.EOF {
+		return Uncompressed, err
+	}
+	return compressionOf(magic[:n]), nil
+}
+
+// compressionOf identifies the compression from the first bytes of a stream.
+func compressionOf(magic []byte) Compression {
+	switch {
+	case bytes.HasPrefix(magic, zstdMagic):
+		return Zstd
+	case bytes.HasPrefix(magic, gzipMagic):
+		return Gzip
+	}
+	return Uncompressed
+}
+
+// NewDecompressor detects the compression of r and returns a reader of the decompressed data,
+// which is r itself, buffered, when it is not compressed.
+func NewDecompressor(r io.Reader) (io.ReadCloser, Compression, error) {
+	br := bufio.NewReaderSize(r, 1024*1024)
+	c, err := DetectCompression(br)
+	if err != nil {
+		return nil, c, err
+	}
+
+	switch c {
+	case Gzip:
+		zr, err := gzip.NewReader(br)
+		return zr, c, err
+	case Zstd:
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, c, err
+		}
+		return zr.IOReadCloser(), c, nil
+	default:
+		return io.NopCloser(br), c, nil
+	}
+}
+
+// NewCompressor returns a writer that compresses to w. Closing it flushes the compressed
+// stream but does not close w.
+func NewCompressor(w io.Writer, c Compression) (io.WriteCloser, error) {
+	switch c {
+	case Gzip:
+		return gzip.NewWriter(w), nil
+	case Zstd:
+		return zstd.NewWriter(w)
+	case Uncompressed:
+		return nopWriteCloser{w}, nil
+	default:
+		return nil, fmt.Errorf("unknown compression %q", string(c))
+	}
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// WriteMeasurementsCompressed writes the same measurements as WriteMeasurementsParallel
+// with the same arguments to fileName, compressed with c.
+func WriteMeasurementsCompressed(fileName string, c Compression, size int, stations []WeatherStation, dist Distribution, seed int64, workers int) error {
+	file, err := os.Create(fileName)
+	if err != nil {
+		return fmt.Errorf("Failed to create file: %v", err)
+	}
+	defer file.Close()
+
+	bw := bufio.NewWriterSize(file, 1024*1024)
+	cw, err := NewCompressor(bw, c)
+	if err != nil {
+		return err
+	}
+	if err := WriteMeasurementsTo(cw, size, stations, dist, seed, workers); err != nil {
+		return fmt.Errorf("Failed to write measurements: %v", err)
+	}
+	if err := cw.Close(); err != nil {
+		return fmt.Errorf("Failed to write measurements: %v", err)
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("Failed to write measurements: %v", err)
+	}
+
+	fmt.Println("Done writing file")
+	return file.Close()
+}
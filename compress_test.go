@@ -0,0 +1,128 @@
+package obrc
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCompressionRoundTrip(t *testing.T) {
+	data := strings.Repeat("Hamburg;12.0\nBulawayo;8.9\n", 10_000)
+
+	for _, c := range []Compression{Uncompressed, Gzip, Zstd} {
+		t.Run(c.String(), func(t *testing.T) {
+			var buf bytes.Buffer
+			cw, err := NewCompressor(&buf, c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := io.WriteString(cw, data); err != nil {
+				t.Fatal(err)
+			}
+			if err := cw.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if c != Uncompressed && buf.Len() >= len(data)/10 {
+				t.Errorf("compressed %d bytes to %d bytes", len(data), buf.Len())
+			}
+
+			r, detected, err := NewDecompressor(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer r.Close()
+			if detected != c {
+				t.Errorf("NewDecompressor() detected %v, want %v", detected, c)
+			}
+			got, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != data {
+				t.Errorf("round trip returned %d bytes, want %d", len(got), len(data))
+			}
+		})
+	}
+}
+
+func TestDetectCompression(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Compression
+	}{
+		{"", Uncompressed},
+		{"A", Uncompressed},
+		{"A;1.0\n", Uncompressed},
+		{"\x1f\x8b", Gzip},
+		{"\x1f\x8b\x08\x00", Gzip},
+		{"\x28\xb5\x2f\xfd\x00", Zstd},
+		{"\x28\xb5\x2f", Uncompressed},
+	}
+	for _, tt := range tests {
+		br := bufio.NewReader(strings.NewReader(tt.input))
+		got, err := DetectCompression(br)
+		if err != nil || got != tt.want {
+			t.Errorf("DetectCompression(%q) = %v, %v; want %v", tt.input, got, err, tt.want)
+		}
+		if rest, _ := io.ReadAll(br); string(rest) != tt.input {
+			t.Errorf("DetectCompression(%q) consumed input", tt.input)
+		}
+
+		got, err = DetectFileCompression(strings.NewReader(tt.input))
+		if err != nil || got != tt.want {
+			t.Errorf("DetectFileCompression(%q) = %v, %v; want %v", tt.input, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseCompression(t *testing.T) {
+	for name, want := range map[string]Compression{"": Uncompressed, "none": Uncompressed, "gzip": Gzip, "zstd": Zstd} {
+		if got, err := ParseCompression(name); err != nil || got != want {
+			t.Errorf("ParseCompression(%q) = %v, %v; want %v", name, got, err, want)
+		}
+	}
+	if _, err := ParseCompression("bzip2"); err == nil {
+		t.Error("ParseCompression(bzip2) did not fail")
+	}
+}
+
+func TestWriteMeasurementsCompressed(t *testing.T) {
+	dir := t.TempDir()
+	plain := filepath.Join(dir, "plain.txt")
+	if err := WriteMeasurementsParallel(plain, 20_000, Stations, DefaultDistribution, 3, 4); err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile(plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []Compression{Gzip, Zstd} {
+		fileName := filepath.Join(dir, "measurements."+c.String())
+		if err := WriteMeasurementsCompressed(fileName, c, 20_000, Stations, DefaultDistribution, 3, 4); err != nil {
+			t.Fatalf("WriteMeasurementsCompressed(%v) error = %v", c, err)
+		}
+
+		f, err := os.Open(fileName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, detected, err := NewDecompressor(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := io.ReadAll(r)
+		r.Close()
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if detected != c || !bytes.Equal(got, want) {
+			t.Errorf("%v: detected %v, decompressed %d bytes; want the %d bytes of WriteMeasurementsParallel", c, detected, len(got), len(want))
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package obrc
+
+import (
+	"context"
+	"io"
+	"os"
+)
+
+// ContextCalculator is implemented by Calculators that stop early when a context is done.
+type ContextCalculator interface {
+	CalculateContext(ctx context.Context, inputFile string) (Results, error)
+}
+
+// ContextFunc is a function type that implements both the Calculator and ContextCalculator interfaces.
+type ContextFunc func(ctx context.Context, inputFile string) (Results, error)
+
+// CalculateContext calls the ContextFunc with the provided context and input.
+func (f ContextFunc) CalculateContext(ctx context.Context, inputFile string) (Results, error) {
+	return f(ctx, inputFile)
+}
+
+// Calculate calls the ContextFunc with a context that is never done.
+func (f ContextFunc) Calculate(inputFile string) (Results, error) {
+	return f(context.Background(), inputFile)
+}
+
+// CalculateContext opens inputFile and streams it through the StreamFunc,
+// stopping at the next read once ctx is done.
+func (f StreamFunc) CalculateContext(ctx context.Context, inputFile string) (Results, error) {
+	file, err := os.Open(inputFile)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return CalculateReaderContext(ctx, f, file)
+}
+
+// CalculateContext runs c on inputFile until it completes or ctx is done.
+//
+// ContextCalculators, which include every StreamFunc, stop cooperatively and release their
+// goroutines before returning. Any other Calculator keeps running in the background after
+// CalculateContext has returned ctx.Err().
+func CalculateContext(ctx context.Context, c Calculator, inputFile string) (Results, error) {
+	if cc, ok := c.(ContextCalculator); ok {
+		return cc.CalculateContext(ctx, inputFile)
+	}
+
+	type outcome struct {
+		results Results
+		err     error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		results, err := c.Calculate(inputFile)
+		done <- outcome{results, err}
+	}()
+
+	select {
+	case o := <-done:
+		return o.results, o.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// CalculateReaderContext streams input through sc, stopping at the next read once ctx is done.
+func CalculateReaderContext(ctx context.Context, sc StreamCalculator, input io.Reader) (Results, error) {
+	results, err := sc.CalculateReader(NewContextReader(ctx, input))
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, ctxErr
+	}
+	return results, err
+}
+
+// NewContextReader returns a reader that fails with ctx.Err() once ctx is done.
+// Implementations that read in large blocks check for cancellation once per block.
+func NewContextReader(ctx context.Context, r io.Reader) io.Reader {
+	return &contextReader{ctx: ctx, r: r}
+}
+
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (cr *contextReader) Read(p []byte) (int, error) {
+	if err := cr.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cr.r.Read(p)
+}
@@ -0,0 +1,123 @@
+package obrc
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// MinMeasurement and MaxMeasurement bound measurements allowed by the 1BRC spec.
+const (
+	MinMeasurement = -99.9
+	MaxMeasurement = 99.9
+)
+
+// Distribution decides which station each generated row belongs to and what it measured.
+// Implementations must be safe for concurrent use by multiple generator workers,
+// each of which passes its own rng.
+type Distribution interface {
+	// Station returns the index of the station for the next row, in [0, n).
+	Station(rng *rand.Rand, n int) int
+	// Measurement returns the measurement of ws on the given row (counted from 0 across the whole file).
+	Measurement(rng *rand.Rand, ws *WeatherStation, row int) float64
+}
+
+// DefaultDistribution matches the original generator: stations are picked uniformly and
+// measurements are normally distributed around the station mean with a standard deviation of 10.
+var DefaultDistribution Distribution = Normal{StdDev: 10, Clamp: true}
+
+// clampMeasurement limits v to the spec range if clamp is set.
+func clampMeasurement(v float64, clamp bool) float64 {
+	if clamp {
+		return max(MinMeasurement, min(MaxMeasurement, v))
+	}
+	return v
+}
+
+// Normal picks stations uniformly and draws normally distributed measurements around the station mean.
+type Normal struct {
+	StdDev float64
+	Clamp  bool // Clamp measurements to [-99.9, 99.9]
+}
+
+// Station picks a station uniformly.
+func (d Normal) Station(rng *rand.Rand, n int) int {
+	return rng.Intn(n)
+}
+
+// Measurement returns the station mean plus normally distributed noise.
+func (d Normal) Measurement(rng *rand.Rand, ws *WeatherStation, row int) float64 {
+	return clampMeasurement(rng.NormFloat64()*d.StdDev+ws.MeanTemperature, d.Clamp)
+}
+
+// Uniform picks stations uniformly and draws measurements uniformly from an interval
+// around the station mean whose standard deviation is StdDev.
+type Uniform struct {
+	StdDev float64
+	Clamp  bool // Clamp measurements to [-99.9, 99.9]
+}
+
+// Station picks a station uniformly.
+func (d Uniform) Station(rng *rand.Rand, n int) int {
+	return rng.Intn(n)
+}
+
+// Measurement returns a value uniformly distributed in mean ± sqrt(3)*StdDev.
+func (d Uniform) Measurement(rng *rand.Rand, ws *WeatherStation, row int) float64 {
+	halfWidth := math.Sqrt(3) * d.StdDev
+	return clampMeasurement(ws.MeanTemperature+(2*rng.Float64()-1)*halfWidth, d.Clamp)
+}
+
+// Zipf picks stations with Zipf distributed popularity, so the first stations are
+// far more frequent than the rest, and draws normally distributed measurements.
+type Zipf struct {
+	StdDev float64
+	Clamp  bool      // Clamp measurements to [-99.9, 99.9]
+	cdf    []float64 // Cumulative probability of picking station 0..n-1
+}
+
+// NewZipf returns a Zipf distribution over n stations where station k (counted from 1)
+// is picked with probability proportional to 1/k^s.
+func NewZipf(s float64, n int, stddev float64, clamp bool) *Zipf {
+	cdf := make([]float64, n)
+	var total float64
+	for k := range n {
+		total += 1 / math.Pow(float64(k+1), s)
+		cdf[k] = total
+	}
+	for k := range cdf {
+		cdf[k] /= total
+	}
+	return &Zipf{StdDev: stddev, Clamp: clamp, cdf: cdf}
+}
+
+// Station picks a station by inverting the cumulative distribution.
+// n must match the station count the distribution was created for.
+func (d *Zipf) Station(rng *rand.Rand, n int) int {
+	return min(sort.SearchFloat64s(d.cdf, rng.Float64()), n-1)
+}
+
+// Measurement returns the station mean plus normally distributed noise.
+func (d *Zipf) Measurement(rng *rand.Rand, ws *WeatherStation, row int) float64 {
+	return clampMeasurement(rng.NormFloat64()*d.StdDev+ws.MeanTemperature, d.Clamp)
+}
+
+// Seasonal picks stations uniformly and adds a sine wave over the rows of the file to the
+// station mean, as if the file covered Period rows worth of seasons, plus normal noise.
+type Seasonal struct {
+	Amplitude float64
+	Period    int // Rows per full cycle
+	StdDev    float64
+	Clamp     bool // Clamp measurements to [-99.9, 99.9]
+}
+
+// Station picks a station uniformly.
+func (d Seasonal) Station(rng *rand.Rand, n int) int {
+	return rng.Intn(n)
+}
+
+// Measurement returns the seasonal mean of the station on row plus normally distributed noise.
+func (d Seasonal) Measurement(rng *rand.Rand, ws *WeatherStation, row int) float64 {
+	season := d.Amplitude * math.Sin(2*math.Pi*float64(row)/float64(max(1, d.Period)))
+	return clampMeasurement(rng.NormFloat64()*d.StdDev+ws.MeanTemperature+season, d.Clamp)
+}
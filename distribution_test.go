@@ -0,0 +1,83 @@
+package obrc
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestDistributionsClamp(t *testing.T) {
+	ws := &WeatherStation{ID: "Hot", MeanTemperature: 90}
+	tests := []struct {
+		name string
+		dist Distribution
+	}{
+		{name: "normal", dist: Normal{StdDev: 50, Clamp: true}},
+		{name: "uniform", dist: Uniform{StdDev: 50, Clamp: true}},
+		{name: "zipf", dist: NewZipf(1.2, 10, 50, true)},
+		{name: "seasonal", dist: Seasonal{Amplitude: 40, Period: 100, StdDev: 50, Clamp: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rng := rand.New(rand.NewSource(1))
+			clamped := 0
+			for row := range 10_000 {
+				m := tt.dist.Measurement(rng, ws, row)
+				if m < MinMeasurement || m > MaxMeasurement {
+					t.Fatalf("Measurement() = %v, outside the spec range", m)
+				}
+				if m == MaxMeasurement {
+					clamped++
+				}
+				if s := tt.dist.Station(rng, 10); s < 0 || s >= 10 {
+					t.Fatalf("Station() = %d, want [0, 10)", s)
+				}
+			}
+			if clamped == 0 {
+				t.Error("expected some measurements to be clamped")
+			}
+		})
+	}
+}
+
+func TestUniformBounds(t *testing.T) {
+	ws := &WeatherStation{ID: "Mild", MeanTemperature: 10}
+	dist := Uniform{StdDev: 1}
+	rng := rand.New(rand.NewSource(1))
+	for row := range 10_000 {
+		if m := dist.Measurement(rng, ws, row); m < 10-1.74 || m > 10+1.74 {
+			t.Fatalf("Measurement() = %v, want within mean ± sqrt(3)*stddev", m)
+		}
+	}
+}
+
+func TestZipfSkew(t *testing.T) {
+	const n = 100
+	dist := NewZipf(1.2, n, 10, true)
+	rng := rand.New(rand.NewSource(1))
+
+	counts := make([]int, n)
+	for range 100_000 {
+		counts[dist.Station(rng, n)]++
+	}
+	if counts[0] < 10*counts[n-1] {
+		t.Errorf("most popular station picked %d times, least popular %d times; want strong skew", counts[0], counts[n-1])
+	}
+	for k := 1; k < 5; k++ {
+		if counts[k] > counts[k-1] {
+			t.Errorf("station %d picked more often than station %d", k, k-1)
+		}
+	}
+}
+
+func TestSeasonal(t *testing.T) {
+	ws := &WeatherStation{ID: "Temperate", MeanTemperature: 10}
+	dist := Seasonal{Amplitude: 20, Period: 400}
+	rng := rand.New(rand.NewSource(1))
+
+	if summer := dist.Measurement(rng, ws, 100); summer != 30 {
+		t.Errorf("Measurement() at a quarter period = %v, want 30", summer)
+	}
+	if winter := dist.Measurement(rng, ws, 300); winter != -10 {
+		t.Errorf("Measurement() at three quarters period = %v, want -10", winter)
+	}
+}
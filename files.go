@@ -0,0 +1,138 @@
+package obrc
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// FileResult holds the results of a single input file.
+type FileResult struct {
+	File    string
+	Results Results
+}
+
+// Rows returns the number of measurements in the file.
+func (fr FileResult) Rows() int64 {
+	return fr.Results.Rows()
+}
+
+// ExpandInputs returns the files named by input in lexical order. input is a file,
+// a directory, whose regular files are returned, or a glob pattern such as "data/*.txt".
+// Hidden files starting with '.' are skipped in directories.
+func ExpandInputs(input string) ([]string, error) {
+	info, err := os.Stat(input)
+	switch {
+	case err == nil && info.IsDir():
+		entries, err := os.ReadDir(input)
+		if err != nil {
+			return nil, err
+		}
+		var files []string
+		for _, e := range entries {
+			if e.Type().IsRegular() && !strings.HasPrefix(e.Name(), ".") {
+				files = append(files, filepath.Join(input, e.Name()))
+			}
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("no files in directory %s", input)
+		}
+		return files, nil
+	case err == nil:
+		return []string{input}, nil
+	}
+
+	matches, globErr := filepath.Glob(input)
+	if globErr != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %v", input, globErr)
+	}
+	var files []string
+	for _, m := range matches {
+		if info, err := os.Stat(m); err == nil && !info.IsDir() {
+			files = append(files, m)
+		}
+	}
+	if len(files) == 0 {
+		if len(matches) == 0 && !strings.ContainsAny(input, "*?[") {
+			// A plain file name that does not exist
+			return nil, err
+		}
+		return nil, fmt.Errorf("no files match %s", input)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// CalculateFiles runs calc on every file using up to workers goroutines, each of which takes the
+// next unprocessed file until none are left, and merges the per-file results into one.
+// The file results are returned in the order of files. The first error cancels the remaining files.
+func CalculateFiles(ctx context.Context, files []string, workers int, calc func(ctx context.Context, fileName string) (Results, error)) (Results, []FileResult, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	workers = max(1, min(workers, len(files)))
+
+	type result struct {
+		index   int
+		results Results
+		err     error
+	}
+
+	jobs := make(chan int, len(files))
+	for i := range files {
+		jobs <- i
+	}
+	close(jobs)
+
+	wg := sync.WaitGroup{}
+	resultChan := make(chan result, workers)
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if ctx.Err() != nil {
+					return
+				}
+				results, err := calc(ctx, files[i])
+				resultChan <- result{i, results, err}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(resultChan)
+	}()
+
+	fileResults := make([]FileResult, len(files))
+	var firstErr error
+	for res := range resultChan {
+		if res.err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s: %w", files[res.index], res.err)
+				cancel()
+			}
+			continue
+		}
+		fileResults[res.index] = FileResult{File: files[res.index], Results: res.results}
+	}
+
+	if firstErr != nil {
+		return nil, nil, firstErr
+	}
+	// Report cancellation by the caller instead of partial results
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	parts := make([]Results, len(fileResults))
+	for i, fr := range fileResults {
+		parts[i] = fr.Results
+	}
+	return Merge(parts...), fileResults, nil
+}
@@ -0,0 +1,114 @@
+package obrc
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	a := Results{{Station: "Oslo", Min: -50, Max: 100, Sum: 150, Count: 3}, {Station: "Abha", Min: 10, Max: 10, Sum: 10, Count: 1}}
+	b := Results{{Station: "Oslo", Min: -60, Max: 90, Sum: 30, Count: 2}}
+	c := Results{{Station: "Cairo", Min: 200, Max: 300, Sum: 500, Count: 2}}
+
+	got := Merge(a, nil, b, c)
+	want := Results{
+		{Station: "Abha", Min: 10, Max: 10, Sum: 10, Count: 1},
+		{Station: "Cairo", Min: 200, Max: 300, Sum: 500, Count: 2},
+		{Station: "Oslo", Min: -60, Max: 100, Sum: 180, Count: 5},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Merge() = %v, want %v", got, want)
+	}
+	if got.Rows() != 8 {
+		t.Errorf("Rows() = %d, want 8", got.Rows())
+	}
+	if a[0].Min != -50 {
+		t.Error("Merge() modified its input")
+	}
+}
+
+func TestExpandInputs(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"b.txt", "a.txt", "c.csv", ".hidden"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "sub.txt"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	join := func(names ...string) []string {
+		for i, name := range names {
+			names[i] = filepath.Join(dir, name)
+		}
+		return names
+	}
+
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{filepath.Join(dir, "b.txt"), join("b.txt")},
+		{dir, join("a.txt", "b.txt", "c.csv")},
+		{filepath.Join(dir, "*.txt"), join("a.txt", "b.txt")},
+		{filepath.Join(dir, "[ac].*"), join("a.txt", "c.csv")},
+	}
+	for _, tt := range tests {
+		got, err := ExpandInputs(tt.input)
+		if err != nil || !slices.Equal(got, tt.want) {
+			t.Errorf("ExpandInputs(%q) = %v, %v; want %v", tt.input, got, err, tt.want)
+		}
+	}
+
+	for _, input := range []string{filepath.Join(dir, "missing.txt"), filepath.Join(dir, "*.json"), filepath.Join(dir, "sub*")} {
+		if got, err := ExpandInputs(input); err == nil {
+			t.Errorf("ExpandInputs(%q) = %v, want an error", input, got)
+		}
+	}
+}
+
+func TestCalculateFiles(t *testing.T) {
+	partials := map[string]Results{
+		"a": {{Station: "Oslo", Min: -10, Max: 10, Sum: 0, Count: 2}},
+		"b": {{Station: "Abha", Min: 5, Max: 5, Sum: 5, Count: 1}},
+		"c": {{Station: "Oslo", Min: -20, Max: 0, Sum: -20, Count: 2}, {Station: "Abha", Min: 1, Max: 1, Sum: 1, Count: 1}},
+		"d": {},
+	}
+	calc := func(ctx context.Context, fileName string) (Results, error) {
+		if r, ok := partials[fileName]; ok {
+			return r, nil
+		}
+		return nil, errors.New("no such file")
+	}
+
+	files := []string{"a", "b", "c", "d"}
+	for workers := 1; workers <= 5; workers++ {
+		results, fileResults, err := CalculateFiles(context.Background(), files, workers, calc)
+		if err != nil {
+			t.Fatalf("CalculateFiles() error = %v", err)
+		}
+		if want := Merge(partials["a"], partials["b"], partials["c"]); !slices.Equal(results, want) {
+			t.Errorf("%d workers: results = %v, want %v", workers, results, want)
+		}
+		for i, fr := range fileResults {
+			if fr.File != files[i] || fr.Rows() != partials[files[i]].Rows() {
+				t.Errorf("%d workers: file result %d = %s with %d rows, want %s with %d rows",
+					workers, i, fr.File, fr.Rows(), files[i], partials[files[i]].Rows())
+			}
+		}
+	}
+
+	if _, _, err := CalculateFiles(context.Background(), []string{"a", "missing", "b"}, 2, calc); err == nil {
+		t.Error("CalculateFiles() with a failing file did not return an error")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := CalculateFiles(ctx, files, 2, calc); !errors.Is(err, context.Canceled) {
+		t.Errorf("CalculateFiles() with cancelled context error = %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,211 @@
+package obrc
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Format writes results in an output format such as JSON.
+type Format struct {
+	Name        string // Name used to select the format, e.g. "json"
+	Description string // Human readable description
+	Extension   string // File name extension of saved results, e.g. ".json"
+	Write       func(w io.Writer, rs Results, opts FormatOptions) error
+}
+
+// FormatOptions holds the options of formats that support them.
+type FormatOptions struct {
+	// Tenths writes min, mean and max as integer tenths of a degree, e.g. -123 for -12.3,
+	// so that consumers do not need to parse decimals. The text format ignores it.
+	Tenths bool
+}
+
+var (
+	formatsMu sync.RWMutex
+	formats   = make(map[string]Format)
+)
+
+// RegisterFormat makes an output format available by name.
+// It panics if the name is already registered or Write is nil.
+func RegisterFormat(f Format) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+
+	if f.Write == nil {
+		panic("obrc: RegisterFormat Write is nil for " + f.Name)
+	}
+	if _, dup := formats[f.Name]; dup {
+		panic("obrc: RegisterFormat called twice for format " + f.Name)
+	}
+	formats[f.Name] = f
+}
+
+// LookupFormat finds a registered output format by name.
+func LookupFormat(name string) (Format, bool) {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+
+	f, ok := formats[name]
+	return f, ok
+}
+
+// Formats returns all registered output formats ordered by name.
+func Formats() []Format {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+
+	fs := make([]Format, 0, len(formats))
+	for _, f := range formats {
+		fs = append(fs, f)
+	}
+	slices.SortFunc(fs, func(a, b Format) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return fs
+}
+
+// FormatNames returns the names of all registered output formats, e.g. for flag help.
+func FormatNames() []string {
+	var names []string
+	for _, f := range Formats() {
+		names = append(names, f.Name)
+	}
+	return names
+}
+
+func init() {
+	RegisterFormat(Format{
+		Name:        "text",
+		Description: "canonical 1BRC output, {Abha=-23.0/18.0/59.2, ...}",
+		Extension:   ".txt",
+		Write: func(w io.Writer, rs Results, _ FormatOptions) error {
+			_, err := rs.WriteTo(w)
+			return err
+		},
+	})
+	RegisterFormat(Format{
+		Name:        "json",
+		Description: "JSON array of {station, min, mean, max, count} objects",
+		Extension:   ".json",
+		Write:       writeJSON,
+	})
+	RegisterFormat(Format{
+		Name:        "ndjson",
+		Description: "one JSON {station, min, mean, max, count} object per line",
+		Extension:   ".ndjson",
+		Write:       writeNDJSON,
+	})
+	RegisterFormat(Format{
+		Name:        "csv",
+		Description: "comma separated values with a header row",
+		Extension:   ".csv",
+		Write: func(w io.Writer, rs Results, opts FormatOptions) error {
+			return writeDelimited(w, rs, opts, ',')
+		},
+	})
+	RegisterFormat(Format{
+		Name:        "tsv",
+		Description: "tab separated values with a header row",
+		Extension:   ".tsv",
+		Write: func(w io.Writer, rs Results, opts FormatOptions) error {
+			return writeDelimited(w, rs, opts, '\t')
+		},
+	})
+}
+
+// formatValue formats a value in tenths of a degree as a decimal or, with opts.Tenths, as an integer.
+func formatValue(t int64, opts FormatOptions) string {
+	if opts.Tenths {
+		return strconv.FormatInt(t, 10)
+	}
+	return FormatTenths(t)
+}
+
+// jsonRow is the JSON object written for a station. The numbers are json.Numbers so that
+// decimals are written exactly as in the text format, e.g. 12.3 rather than 12.299999.
+type jsonRow struct {
+	Station string      `json:"station"`
+	Min     json.Number `json:"min"`
+	Mean    json.Number `json:"mean"`
+	Max     json.Number `json:"max"`
+	Count   int64       `json:"count"`
+}
+
+func newJSONRow(r StationResult, opts FormatOptions) jsonRow {
+	return jsonRow{
+		Station: r.Station,
+		Min:     json.Number(formatValue(r.Min, opts)),
+		Mean:    json.Number(formatValue(r.Mean(), opts)),
+		Max:     json.Number(formatValue(r.Max, opts)),
+		Count:   r.Count,
+	}
+}
+
+// writeJSON writes a JSON array with one station object per line.
+func writeJSON(w io.Writer, rs Results, opts FormatOptions) error {
+	bw := bufio.NewWriter(w)
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+
+	bw.WriteString("[")
+	for i, r := range rs {
+		buf.Reset()
+		if err := enc.Encode(newJSONRow(r, opts)); err != nil {
+			return err
+		}
+		if i > 0 {
+			bw.WriteString(",")
+		}
+		bw.WriteString("\n  ")
+		bw.Write(bytes.TrimSuffix(buf.Bytes(), []byte{'\n'}))
+	}
+	if len(rs) > 0 {
+		bw.WriteString("\n")
+	}
+	bw.WriteString("]\n")
+	return bw.Flush()
+}
+
+// writeNDJSON writes one JSON station object per line.
+func writeNDJSON(w io.Writer, rs Results, opts FormatOptions) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	enc.SetEscapeHTML(false)
+	for _, r := range rs {
+		if err := enc.Encode(newJSONRow(r, opts)); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// writeDelimited writes a header and one record per station, quoting station names that
+// contain the delimiter, quotes or newlines.
+func writeDelimited(w io.Writer, rs Results, opts FormatOptions, delimiter rune) error {
+	cw := csv.NewWriter(w)
+	cw.Comma = delimiter
+	cw.Write([]string{"station", "min", "mean", "max", "count"})
+	for _, r := range rs {
+		cw.Write([]string{
+			r.Station,
+			formatValue(r.Min, opts),
+			formatValue(r.Mean(), opts),
+			formatValue(r.Max, opts),
+			strconv.FormatInt(r.Count, 10),
+		})
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("Failed to write results to output: %v", err)
+	}
+	return nil
+}
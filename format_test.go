@@ -0,0 +1,137 @@
+package obrc
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+)
+
+var formatResults = Results{
+	{Station: "Abha", Min: -230, Max: 592, Sum: 360, Count: 2},
+	{Station: "St. John's, NL", Min: 152, Max: 152, Sum: 152, Count: 1},
+	{Station: "Ürümqi <West>", Min: -5, Max: 0, Sum: -5, Count: 2},
+}
+
+func TestFormats(t *testing.T) {
+	tests := []struct {
+		format string
+		opts   FormatOptions
+		want   string
+	}{
+		{"text", FormatOptions{}, "{Abha=-23.0/18.0/59.2, St. John's, NL=15.2/15.2/15.2, Ürümqi <West>=-0.5/-0.2/0.0}\n"},
+		{"json", FormatOptions{}, `[
+  {"station":"Abha","min":-23.0,"mean":18.0,"max":59.2,"count":2},
+  {"station":"St. John's, NL","min":15.2,"mean":15.2,"max":15.2,"count":1},
+  {"station":"Ürümqi <West>","min":-0.5,"mean":-0.2,"max":0.0,"count":2}
+]
+`},
+		{"json", FormatOptions{Tenths: true}, `[
+  {"station":"Abha","min":-230,"mean":180,"max":592,"count":2},
+  {"station":"St. John's, NL","min":152,"mean":152,"max":152,"count":1},
+  {"station":"Ürümqi <West>","min":-5,"mean":-2,"max":0,"count":2}
+]
+`},
+		{"ndjson", FormatOptions{}, `{"station":"Abha","min":-23.0,"mean":18.0,"max":59.2,"count":2}
+{"station":"St. John's, NL","min":15.2,"mean":15.2,"max":15.2,"count":1}
+{"station":"Ürümqi <West>","min":-0.5,"mean":-0.2,"max":0.0,"count":2}
+`},
+		{"csv", FormatOptions{}, `station,min,mean,max,count
+Abha,-23.0,18.0,59.2,2
+"St. John's, NL",15.2,15.2,15.2,1
+Ürümqi <West>,-0.5,-0.2,0.0,2
+`},
+		{"tsv", FormatOptions{Tenths: true}, "station\tmin\tmean\tmax\tcount\n" +
+			"Abha\t-230\t180\t592\t2\n" +
+			"St. John's, NL\t152\t152\t152\t1\n" +
+			"Ürümqi <West>\t-5\t-2\t0\t2\n"},
+	}
+	for _, tt := range tests {
+		f, ok := LookupFormat(tt.format)
+		if !ok {
+			t.Fatalf("LookupFormat(%q) failed", tt.format)
+		}
+		var buf bytes.Buffer
+		if err := f.Write(&buf, formatResults, tt.opts); err != nil {
+			t.Fatalf("%s: %v", tt.format, err)
+		}
+		if buf.String() != tt.want {
+			t.Errorf("%s %+v wrote\n%s\nwant\n%s", tt.format, tt.opts, buf.String(), tt.want)
+		}
+	}
+}
+
+func TestFormatsEmptyResults(t *testing.T) {
+	want := map[string]string{
+		"text":   "{}\n",
+		"json":   "[]\n",
+		"ndjson": "",
+		"csv":    "station,min,mean,max,count\n",
+		"tsv":    "station\tmin\tmean\tmax\tcount\n",
+	}
+	for _, f := range Formats() {
+		var buf bytes.Buffer
+		if err := f.Write(&buf, nil, FormatOptions{}); err != nil {
+			t.Fatalf("%s: %v", f.Name, err)
+		}
+		if w, ok := want[f.Name]; ok && buf.String() != w {
+			t.Errorf("%s wrote %q for no results, want %q", f.Name, buf.String(), w)
+		}
+	}
+}
+
+func TestFormatsRoundTrip(t *testing.T) {
+	// Parse the machine-readable formats with standard decoders and compare with the results
+	type row struct {
+		Station        string
+		Min, Mean, Max json.Number
+		Count          int64
+	}
+	want := make([]row, len(formatResults))
+	for i, r := range formatResults {
+		want[i] = row{r.Station, json.Number(FormatTenths(r.Min)), json.Number(FormatTenths(r.Mean())), json.Number(FormatTenths(r.Max)), r.Count}
+	}
+
+	var buf bytes.Buffer
+	f, _ := LookupFormat("json")
+	f.Write(&buf, formatResults, FormatOptions{})
+	var got []row
+	dec := json.NewDecoder(&buf)
+	dec.UseNumber()
+	if err := dec.Decode(&got); err != nil || !slices.Equal(got, want) {
+		t.Errorf("json decoded to %+v, %v; want %+v", got, err, want)
+	}
+
+	buf.Reset()
+	f, _ = LookupFormat("tsv")
+	f.Write(&buf, formatResults, FormatOptions{})
+	cr := csv.NewReader(&buf)
+	cr.Comma = '\t'
+	records, err := cr.ReadAll()
+	if err != nil || len(records) != len(want)+1 {
+		t.Fatalf("tsv parsed to %q, %v", records, err)
+	}
+	for i, rec := range records[1:] {
+		if rec[0] != want[i].Station || rec[1] != string(want[i].Min) || rec[2] != string(want[i].Mean) || rec[3] != string(want[i].Max) {
+			t.Errorf("tsv record %d = %q, want %+v", i, rec, want[i])
+		}
+	}
+}
+
+func TestRegisterFormat(t *testing.T) {
+	if names := FormatNames(); !slices.IsSorted(names) || !slices.Contains(names, "json") {
+		t.Errorf("FormatNames() = %v, want sorted names including json", names)
+	}
+	if _, ok := LookupFormat("xml"); ok {
+		t.Error("LookupFormat(xml) succeeded")
+	}
+
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(r.(string), "twice") {
+			t.Errorf("registering json twice recovered %v, want a panic", r)
+		}
+	}()
+	RegisterFormat(Format{Name: "json", Write: writeJSON})
+}
@@ -8,38 +8,52 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+
+	obrc "github.com/tyleryarnell/1brc"
+	"github.com/tyleryarnell/1brc/internal/lines"
 )
 
 // Calculate reads the input and calculates the average values for each station
-// and writes the results to the provided output writer.
-func Calculate(inputFile string, output io.Writer) error {
+// and returns the results ordered by station name.
+func Calculate(inputFile string) (obrc.Results, error) {
 
 	// Open the file to be processed
 	file, err := os.Open(inputFile)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer file.Close()
 
+	return CalculateReader(file)
+}
+
+// CalculateReader reads measurements from input and calculates the min, average, and max values for each station
+func CalculateReader(input io.Reader) (obrc.Results, error) {
 	type stats struct {
 		min, max, sum float64
 		count         int
 	}
 
 	measurements := make(map[string]stats)
-	scanner := bufio.NewScanner(file)
+	scanner := bufio.NewScanner(input)
 
+	first := true
 	for scanner.Scan() {
-		line := scanner.Text()
+		// bufio.ScanLines already drops the '\r' of a "\r\n" terminator
+		row := scanner.Bytes()
+		if first {
+			row, first = lines.TrimBOM(row), false
+		}
+		line := string(row)
 		parts := strings.Split(line, ";")
 		if len(parts) != 2 {
-			panic(fmt.Sprintf("Malformed line: %q", line))
+			return nil, obrc.MalformedRowError(row)
 		}
 
 		station := parts[0]
 		value, err := strconv.ParseFloat(parts[1], 64)
-		if err != nil {
-			panic(fmt.Sprintf("Failed to parse value %q: %v", parts[1], err))
+		if err != nil || !obrc.IsTemperature(parts[1]) {
+			return nil, obrc.MalformedRowError(row)
 		}
 
 		if _, exists := measurements[station]; !exists {
@@ -57,7 +71,7 @@ func Calculate(inputFile string, output io.Writer) error {
 	}
 
 	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("Error reading input: %v", err)
+		return nil, fmt.Errorf("Error reading input: %v", err)
 	}
 
 	// Sort the station names
@@ -67,23 +81,18 @@ func Calculate(inputFile string, output io.Writer) error {
 	}
 	sort.Strings(sortedKeys)
 
-	// Write results to the output writer
-	var builder strings.Builder
-	builder.WriteString("{")
-	for i, station := range sortedKeys {
-		res := fmt.Sprintf("%s=%.1f/%.1f/%.1f", station,
-			measurements[station].min,
-			measurements[station].sum/float64(measurements[station].count),
-			measurements[station].max)
-		builder.WriteString(res)
-		if i < len(sortedKeys)-1 {
-			builder.WriteString(", ")
-		}
-	}
-	builder.WriteString("}\n")
-	if _, err := output.Write([]byte(builder.String())); err != nil {
-		return fmt.Errorf("Failed to write results to output: %v", err)
+	// Collect the results in station order
+	results := make(obrc.Results, 0, len(sortedKeys))
+	for _, station := range sortedKeys {
+		m := measurements[station]
+		results = append(results, obrc.StationResult{
+			Station: station,
+			Min:     obrc.ToTenths(m.min),
+			Max:     obrc.ToTenths(m.max),
+			Sum:     obrc.ToTenths(m.sum),
+			Count:   int64(m.count),
+		})
 	}
 
-	return nil
+	return results, nil
 }
@@ -0,0 +1,13 @@
+package baseline
+
+import obrc "github.com/tyleryarnell/1brc"
+
+func init() {
+	obrc.Register(obrc.Implementation{
+		Version:     0,
+		Name:        "baseline",
+		Description: "default (baseline)",
+		Tags:        []string{"scanner", "stream"},
+		Calculator:  obrc.StreamFunc(CalculateReader),
+	})
+}
@@ -0,0 +1,51 @@
+// Package chunk splits the input of the parallel implementations into line-aligned chunks,
+// so that every row is processed by exactly one worker.
+package chunk
+
+import "bytes"
+
+// Chunk is the byte range [Start, End) of an input.
+type Chunk struct {
+	Start, End int64
+}
+
+// Len returns the number of bytes in the chunk.
+func (c Chunk) Len() int64 {
+	return c.End - c.Start
+}
+
+// Plan splits data into at most n line-aligned chunks of roughly equal size.
+//
+// The chunks cover data exactly once, in order and without gaps. Every chunk is non-empty
+// and ends just after a '\n', except the last one, which ends at len(data) whether or not
+// the input has a trailing newline. Empty input has no chunks, and input with fewer lines
+// than n has fewer chunks.
+func Plan(data []byte, n int) []Chunk {
+	n = max(n, 1)
+	size := int64(len(data))
+	chunks := make([]Chunk, 0, min(int64(n), size))
+
+	for start := int64(0); start < size; {
+		// Aim for the first of the n-1 equally spaced boundaries after start, then move the
+		// end forward so that the chunk finishes with a complete line. Every chunk but the
+		// last passes a different boundary, so there are at most n chunks.
+		target := size
+		for i := int64(1); i < int64(n); i++ {
+			if boundary := i * size / int64(n); boundary > start {
+				target = boundary
+				break
+			}
+		}
+
+		end := size
+		if target < size {
+			if i := bytes.IndexByte(data[target-1:], '\n'); i >= 0 {
+				end = target + int64(i)
+			}
+		}
+
+		chunks = append(chunks, Chunk{Start: start, End: end})
+		start = end
+	}
+	return chunks
+}
@@ -0,0 +1,109 @@
+package chunk
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+	"testing/quick"
+)
+
+// checkPlan verifies that chunks cover data exactly once with line-aligned, non-empty chunks.
+func checkPlan(t *testing.T, data []byte, n int, chunks []Chunk) bool {
+	t.Helper()
+	if len(chunks) > max(n, 1) {
+		t.Errorf("Plan(%q, %d) returned %d chunks", data, n, len(chunks))
+		return false
+	}
+	if len(data) == 0 {
+		if len(chunks) != 0 {
+			t.Errorf("Plan(empty, %d) = %v, want no chunks", n, chunks)
+			return false
+		}
+		return true
+	}
+
+	var next int64
+	for i, c := range chunks {
+		switch {
+		case c.Start != next:
+			t.Errorf("Plan(%q, %d) chunk %d = %v starts at %d, want %d", data, n, i, c, c.Start, next)
+			return false
+		case c.Len() <= 0:
+			t.Errorf("Plan(%q, %d) chunk %d = %v is empty", data, n, i, c)
+			return false
+		case i < len(chunks)-1 && data[c.End-1] != '\n':
+			t.Errorf("Plan(%q, %d) chunk %d = %v splits a line", data, n, i, c)
+			return false
+		}
+		next = c.End
+	}
+	if next != int64(len(data)) {
+		t.Errorf("Plan(%q, %d) covers %d of %d bytes", data, n, next, len(data))
+		return false
+	}
+	return true
+}
+
+// lines builds input from line lengths, optionally without the trailing newline.
+func lines(lengths []uint8, trailingNewline bool) []byte {
+	var buf bytes.Buffer
+	for i, l := range lengths {
+		buf.WriteString(strings.Repeat(string(rune('a'+i%26)), int(l%120)))
+		buf.WriteByte('\n')
+	}
+	data := buf.Bytes()
+	if !trailingNewline {
+		data = bytes.TrimSuffix(data, []byte{'\n'})
+	}
+	return data
+}
+
+func TestPlanCoversInput(t *testing.T) {
+	property := func(lengths []uint8, n uint8, trailingNewline bool) bool {
+		data := lines(lengths, trailingNewline)
+		return checkPlan(t, data, int(n%70), Plan(data, int(n%70)))
+	}
+	if err := quick.Check(property, &quick.Config{MaxCount: 2000}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPlan(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		n    int
+		want []Chunk
+	}{
+		{"empty", "", 4, nil},
+		{"single line", "A;1.0\n", 4, []Chunk{{0, 6}}},
+		{"single line without trailing newline", "A;1.0", 4, []Chunk{{0, 5}}},
+		{"fewer lines than chunks", "A;1.0\nB;2.0\n", 8, []Chunk{{0, 6}, {6, 12}}},
+		{"zero chunks means one", "A;1.0\nB;2.0\n", 0, []Chunk{{0, 12}}},
+		{"exact boundaries", "A;1.0\nB;2.0\nC;3.0\n", 3, []Chunk{{0, 6}, {6, 12}, {12, 18}}},
+		{"missing trailing newline", "A;1.0\nB;2.0\nC;3.0", 3, []Chunk{{0, 6}, {6, 12}, {12, 17}}},
+		{"long first line", "Abcdefghijklmnop;1.0\nB;2.0\nC;3.0\n", 4, []Chunk{{0, 21}, {21, 27}, {27, 33}}},
+		{"empty lines", "\n\n\n\n", 2, []Chunk{{0, 2}, {2, 4}}},
+	}
+	for _, tt := range tests {
+		got := Plan([]byte(tt.data), tt.n)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: Plan(%q, %d) = %v, want %v", tt.name, tt.data, tt.n, got, tt.want)
+		}
+		checkPlan(t, []byte(tt.data), tt.n, got)
+	}
+}
+
+func TestPlanBalanced(t *testing.T) {
+	data := bytes.Repeat([]byte("Hamburg;12.0\n"), 100_000)
+	chunks := Plan(data, 8)
+	if len(chunks) != 8 {
+		t.Fatalf("Plan() returned %d chunks, want 8", len(chunks))
+	}
+	for _, c := range chunks {
+		if diff := c.Len() - int64(len(data)/8); diff < -13 || diff > 13 {
+			t.Errorf("chunk %v is %d bytes, want %d ± one line", c, c.Len(), len(data)/8)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+// Package columnar registers output formats that write results as an Apache Arrow IPC
+// file ("arrow") or as Parquet ("parquet"), for analytics tools that read columnar data.
+// Import it for its side effects, like internal/versions.
+//
+// Both formats write one row per station with the columns station (string), min, mean
+// and max (float64, in degrees), count (int64) and sum (int64, in tenths of a degree, so
+// that it is exact). FormatOptions.Tenths does not change the schema.
+package columnar
+
+import (
+	"io"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/compress"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+
+	obrc "github.com/tyleryarnell/1brc"
+)
+
+func init() {
+	obrc.RegisterFormat(obrc.Format{
+		Name:        "arrow",
+		Description: "Apache Arrow IPC file with one row per station",
+		Extension:   ".arrow",
+		Write:       WriteArrow,
+	})
+	obrc.RegisterFormat(obrc.Format{
+		Name:        "parquet",
+		Description: "Parquet file with one row per station",
+		Extension:   ".parquet",
+		Write:       WriteParquet,
+	})
+}
+
+// Schema is the schema of the per-station aggregate table.
+var Schema = arrow.NewSchema([]arrow.Field{
+	{Name: "station", Type: arrow.BinaryTypes.String},
+	{Name: "min", Type: arrow.PrimitiveTypes.Float64},
+	{Name: "mean", Type: arrow.PrimitiveTypes.Float64},
+	{Name: "max", Type: arrow.PrimitiveTypes.Float64},
+	{Name: "count", Type: arrow.PrimitiveTypes.Int64},
+	{Name: "sum", Type: arrow.PrimitiveTypes.Int64, Metadata: arrow.NewMetadata([]string{"unit"}, []string{"tenths of a degree"})},
+}, nil)
+
+// RecordBatch converts results to a record batch of Schema. The caller must release it.
+func RecordBatch(rs obrc.Results) arrow.RecordBatch {
+	b := array.NewRecordBuilder(memory.DefaultAllocator, Schema)
+	defer b.Release()
+
+	station := b.Field(0).(*array.StringBuilder)
+	minimum := b.Field(1).(*array.Float64Builder)
+	mean := b.Field(2).(*array.Float64Builder)
+	maximum := b.Field(3).(*array.Float64Builder)
+	count := b.Field(4).(*array.Int64Builder)
+	sum := b.Field(5).(*array.Int64Builder)
+	for _, r := range rs {
+		station.Append(r.Station)
+		minimum.Append(degrees(r.Min))
+		mean.Append(degrees(r.Mean()))
+		maximum.Append(degrees(r.Max))
+		count.Append(r.Count)
+		sum.Append(r.Sum)
+	}
+	return b.NewRecordBatch()
+}
+
+// degrees converts tenths of a degree to the nearest float64.
+func degrees(tenths int64) float64 {
+	return float64(tenths) / 10
+}
+
+// WriteArrow writes results as an Arrow IPC file holding a single record batch.
+func WriteArrow(w io.Writer, rs obrc.Results, _ obrc.FormatOptions) error {
+	rec := RecordBatch(rs)
+	defer rec.Release()
+
+	fw, err := ipc.NewFileWriter(w, ipc.WithSchema(Schema))
+	if err != nil {
+		return err
+	}
+	if err := fw.Write(rec); err != nil {
+		fw.Close()
+		return err
+	}
+	return fw.Close()
+}
+
+// WriteParquet writes results as a zstd compressed Parquet file with a single row group.
+func WriteParquet(w io.Writer, rs obrc.Results, _ obrc.FormatOptions) error {
+	rec := RecordBatch(rs)
+	defer rec.Release()
+	table := array.NewTableFromRecords(Schema, []arrow.RecordBatch{rec})
+	defer table.Release()
+
+	props := parquet.NewWriterProperties(parquet.WithCompression(compress.Codecs.Zstd))
+	// The parquet writer closes writers that are io.Closers, hide Close so that
+	// the caller's file or stdout stays open
+	return pqarrow.WriteTable(table, struct{ io.Writer }{w}, max(table.NumRows(), 1), props, pqarrow.DefaultWriterProps())
+}
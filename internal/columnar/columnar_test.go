@@ -0,0 +1,132 @@
+package columnar
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+
+	obrc "github.com/tyleryarnell/1brc"
+)
+
+var results = obrc.Results{
+	{Station: "Abha", Min: -230, Max: 592, Sum: 360, Count: 2},
+	{Station: "St. John's", Min: 152, Max: 152, Sum: 152, Count: 1},
+	{Station: "Ürümqi", Min: -999, Max: 999, Sum: -5, Count: 3},
+}
+
+// row is a station read back from a file.
+type row struct {
+	station        string
+	min, mean, max float64
+	count, sum     int64
+}
+
+func wantRows(rs obrc.Results) []row {
+	var rows []row
+	for _, r := range rs {
+		rows = append(rows, row{r.Station, float64(r.Min) / 10, float64(r.Mean()) / 10, float64(r.Max) / 10, r.Count, r.Sum})
+	}
+	return rows
+}
+
+// readRows reads the stations from the columns of a record batch or table chunk.
+func readRows(t *testing.T, cols []arrow.Array) []row {
+	t.Helper()
+	station := cols[0].(*array.String)
+	minimum, mean, maximum := cols[1].(*array.Float64), cols[2].(*array.Float64), cols[3].(*array.Float64)
+	count, sum := cols[4].(*array.Int64), cols[5].(*array.Int64)
+	var rows []row
+	for i := range station.Len() {
+		rows = append(rows, row{station.Value(i), minimum.Value(i), mean.Value(i), maximum.Value(i), count.Value(i), sum.Value(i)})
+	}
+	return rows
+}
+
+func TestArrowRoundTrip(t *testing.T) {
+	for _, rs := range []obrc.Results{results, nil} {
+		var buf bytes.Buffer
+		if err := WriteArrow(&buf, rs, obrc.FormatOptions{}); err != nil {
+			t.Fatal(err)
+		}
+
+		r, err := ipc.NewFileReader(bytes.NewReader(buf.Bytes()), ipc.WithAllocator(memory.DefaultAllocator))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !r.Schema().Equal(Schema) {
+			t.Errorf("schema = %v, want %v", r.Schema(), Schema)
+		}
+		var got []row
+		for i := range r.NumRecords() {
+			rec, err := r.RecordBatch(i)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, readRows(t, rec.Columns())...)
+		}
+		r.Close()
+		if want := wantRows(rs); !slices.Equal(got, want) {
+			t.Errorf("read back %+v, want %+v", got, want)
+		}
+	}
+}
+
+func TestParquetRoundTrip(t *testing.T) {
+	for _, rs := range []obrc.Results{results, nil} {
+		var buf bytes.Buffer
+		if err := WriteParquet(&buf, rs, obrc.FormatOptions{}); err != nil {
+			t.Fatal(err)
+		}
+
+		pf, err := file.NewParquetReader(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		fr, err := pqarrow.NewFileReader(pf, pqarrow.ArrowReadProperties{}, memory.DefaultAllocator)
+		if err != nil {
+			t.Fatal(err)
+		}
+		table, err := fr.ReadTable(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if table.NumCols() != int64(len(Schema.Fields())) {
+			t.Fatalf("read back %d columns, want %d", table.NumCols(), len(Schema.Fields()))
+		}
+		for i, f := range Schema.Fields() {
+			if got := table.Schema().Field(i); got.Name != f.Name || !arrow.TypeEqual(got.Type, f.Type) {
+				t.Errorf("column %d = %v, want %v", i, got, f)
+			}
+		}
+
+		tr := array.NewTableReader(table, -1)
+		var got []row
+		for tr.Next() {
+			got = append(got, readRows(t, tr.RecordBatch().Columns())...)
+		}
+		tr.Release()
+		table.Release()
+		pf.Close()
+		if want := wantRows(rs); !slices.Equal(got, want) {
+			t.Errorf("read back %+v, want %+v", got, want)
+		}
+	}
+}
+
+func TestFormatsRegistered(t *testing.T) {
+	for _, name := range []string{"arrow", "parquet"} {
+		f, ok := obrc.LookupFormat(name)
+		if !ok || f.Extension != "."+name {
+			t.Errorf("LookupFormat(%q) = %+v, %v", name, f, ok)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package keyhash_test
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	obrc "github.com/tyleryarnell/1brc"
+	"github.com/tyleryarnell/1brc/internal/keyhash"
+	"github.com/tyleryarnell/1brc/internal/lphash"
+)
+
+// datasets returns the station name sets the hash functions are measured on.
+func datasets() []struct {
+	name string
+	keys [][]byte
+} {
+	names := func(stations []obrc.WeatherStation) [][]byte {
+		keys := make([][]byte, len(stations))
+		for i, s := range stations {
+			keys[i] = []byte(s.ID)
+		}
+		return keys
+	}
+	return []struct {
+		name string
+		keys [][]byte
+	}{
+		{"stations", names(obrc.Stations)},
+		{"synthetic10k", names(obrc.SyntheticStations(10_000, obrc.UniformNameLengths(1, 100), rand.New(rand.NewSource(1))))},
+		{"synthetic10k-short", names(obrc.SyntheticStations(10_000, obrc.NormalNameLengths(8, 3), rand.New(rand.NewSource(2))))},
+	}
+}
+
+// collisions counts keys whose 64-bit hash equals the hash of an earlier key.
+func collisions(keys [][]byte, h keyhash.Func) int {
+	seen := make(map[uint64]bool, len(keys))
+	n := 0
+	for _, key := range keys {
+		hash := h(key)
+		if seen[hash] {
+			n++
+		}
+		seen[hash] = true
+	}
+	return n
+}
+
+// probeLengths inserts keys into a linear probing table that is at most half full, like an
+// lphash.Table, and returns the average and maximum number of slots a lookup of a key inspects.
+func probeLengths(keys [][]byte, h keyhash.Func) (avg float64, longest int) {
+	size := 1
+	for size < 2*len(keys) {
+		size *= 2
+	}
+	mask := uint64(size - 1)
+	used := make([]bool, size)
+
+	total := 0
+	for _, key := range keys {
+		probes := 1
+		i := h(key) & mask
+		for used[i] {
+			i = (i + 1) & mask
+			probes++
+		}
+		used[i] = true
+		total += probes
+		longest = max(longest, probes)
+	}
+	return float64(total) / float64(len(keys)), longest
+}
+
+func TestCollisions(t *testing.T) {
+	for _, ds := range datasets() {
+		for _, n := range keyhash.All {
+			c := collisions(ds.keys, n.Hash)
+			if n.Name == "first8len" {
+				t.Logf("%s: %s has %d collisions", ds.name, n.Name, c)
+				continue
+			}
+			if c != 0 {
+				t.Errorf("%s: %s has %d collisions", ds.name, n.Name, c)
+			}
+		}
+	}
+
+	if keyhash.First8Len([]byte("Hamburg-Altona")) != keyhash.First8Len([]byte("Hamburg-Harbur")) {
+		t.Error("First8Len hashes keys with the same first eight bytes and length differently")
+	}
+}
+
+// BenchmarkHash measures hashing every key of each dataset, and reports the collisions and
+// probe lengths in a half full linear probing table for each hash function.
+func BenchmarkHash(b *testing.B) {
+	for _, ds := range datasets() {
+		var bytes int64
+		for _, key := range ds.keys {
+			bytes += int64(len(key))
+		}
+		for _, n := range keyhash.All {
+			b.Run(fmt.Sprintf("%s/%s", ds.name, n.Name), func(b *testing.B) {
+				b.SetBytes(bytes / int64(len(ds.keys)))
+				var sink uint64
+				for i := range b.N {
+					sink += n.Hash(ds.keys[i%len(ds.keys)])
+				}
+				_ = sink
+
+				avg, longest := probeLengths(ds.keys, n.Hash)
+				b.ReportMetric(float64(collisions(ds.keys, n.Hash)), "collisions")
+				b.ReportMetric(avg, "avg-probes")
+				b.ReportMetric(float64(longest), "max-probes")
+			})
+		}
+	}
+}
+
+// BenchmarkTable measures aggregating measurements into an lphash.Table using each hash function.
+func BenchmarkTable(b *testing.B) {
+	for _, ds := range datasets() {
+		rng := rand.New(rand.NewSource(3))
+		order := make([]int, 1<<16)
+		for i := range order {
+			order[i] = rng.Intn(len(ds.keys))
+		}
+		for _, n := range keyhash.All {
+			b.Run(fmt.Sprintf("%s/%s", ds.name, n.Name), func(b *testing.B) {
+				table := lphash.New[lphash.Stats](lphash.Config{Hash: lphash.HashFunc(n.Hash)})
+				for i := range b.N {
+					s, _ := table.Upsert(ds.keys[order[i&(len(order)-1)]])
+					s.Add(int32(i & 1023))
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,130 @@
+// Package keyhash provides hash functions for station name keys, from the byte-at-a-time
+// FNV-1a used by the original tables to word-at-a-time alternatives.
+package keyhash
+
+import (
+	"encoding/binary"
+	"hash/maphash"
+	"math/bits"
+)
+
+// Func hashes a key. Keys that are equal have equal hashes.
+type Func func(key []byte) uint64
+
+// Named is a hash function with a name for tests and benchmarks.
+type Named struct {
+	Name string
+	Hash Func
+}
+
+// All lists the hash functions of the package, so that tests and benchmarks cover each.
+var All = []Named{
+	{"fnv1a", FNV1a},
+	{"wyhash", Wy},
+	{"first8len", First8Len},
+	{"words", Words},
+	{"maphash", MapHash},
+}
+
+// FNV1a is the 64-bit FNV-1a hash, which processes one byte at a time.
+func FNV1a(key []byte) uint64 {
+	const (
+		offset64 = 14695981039346656037
+		prime64  = 1099511628211
+	)
+	hash := uint64(offset64)
+	for _, c := range key {
+		hash ^= uint64(c)
+		hash *= prime64
+	}
+	return hash
+}
+
+// Constants of wyhash.
+const (
+	wyp0 = 0xa0761d6478bd642f
+	wyp1 = 0xe7037ed1a0b428db
+	wyp2 = 0x8ebc6af09c88c6e3
+	wyp3 = 0x589965cc75374cc3
+)
+
+// mix multiplies a and b to 128 bits and folds the halves together.
+func mix(a, b uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	return hi ^ lo
+}
+
+// Wy is a wyhash-style hash that consumes eight bytes per multiply. It uses the wyhash
+// constants and mixing but is not bit-compatible with any wyhash release.
+func Wy(key []byte) uint64 {
+	n := len(key)
+	h := wyp0 ^ uint64(n)
+	for len(key) > 8 {
+		h = mix(binary.LittleEndian.Uint64(key)^wyp1, h^wyp2)
+		key = key[8:]
+	}
+	return mix(mix(loadTail(key)^wyp1, h^wyp3), uint64(n)^wyp1)
+}
+
+// WordsSeed is the initial state of Words.
+const WordsSeed = wyp0
+
+// MixWord adds the next eight bytes of a key, loaded little endian, to the state of Words.
+func MixWord(h, word uint64) uint64 {
+	return mix(word^wyp1, h^wyp2)
+}
+
+// Words hashes key as a sequence of little endian words, the last of which holds the
+// remaining zero to seven bytes padded with zeros. A scanner that reads its input a word
+// at a time can compute the same hash while it searches for the end of the key: start with
+// WordsSeed, MixWord every word before the one holding the delimiter, then MixWord that
+// word with the delimiter and everything after it cleared.
+func Words(key []byte) uint64 {
+	h := uint64(WordsSeed)
+	for len(key) >= 8 {
+		h = MixWord(h, binary.LittleEndian.Uint64(key))
+		key = key[8:]
+	}
+	var tail uint64
+	for i, c := range key {
+		tail |= uint64(c) << (8 * i)
+	}
+	return MixWord(h, tail)
+}
+
+// First8Len hashes only the first eight bytes of the key and its length, with a single
+// multiply. It is the cheapest hash here, but keys that share their first eight bytes and
+// their length collide, e.g. "Hamburg-Altona" and "Hamburg-Harbur".
+func First8Len(key []byte) uint64 {
+	var word uint64
+	if len(key) >= 8 {
+		word = binary.LittleEndian.Uint64(key)
+	} else {
+		word = loadTail(key)
+	}
+	return mix(word^wyp1, uint64(len(key))^wyp2)
+}
+
+// loadTail reads a key of up to eight bytes into a word without reading past its end.
+// The loads may overlap but always cover every byte, so different keys of the same length
+// load different words.
+func loadTail(key []byte) uint64 {
+	switch n := len(key); {
+	case n == 8:
+		return binary.LittleEndian.Uint64(key)
+	case n >= 4:
+		return uint64(binary.LittleEndian.Uint32(key)) | uint64(binary.LittleEndian.Uint32(key[n-4:]))<<32
+	case n > 0:
+		return uint64(key[0])<<16 | uint64(key[n>>1])<<8 | uint64(key[n-1])
+	default:
+		return 0
+	}
+}
+
+var seed = maphash.MakeSeed()
+
+// MapHash is hash/maphash with a seed chosen at random when the program starts,
+// so its values differ between runs.
+func MapHash(key []byte) uint64 {
+	return maphash.Bytes(seed, key)
+}
@@ -0,0 +1,56 @@
+package keyhash
+
+import "testing"
+
+func TestEqualKeysHashEqually(t *testing.T) {
+	for _, n := range All {
+		for length := range 40 {
+			a := make([]byte, length, 64)
+			for i := range a {
+				a[i] = byte('a' + i)
+			}
+			// Same key at a different address with different bytes after its end
+			b := append(make([]byte, 0, 64), a...)
+			b = append(b, "trailing"...)[:length]
+			if n.Hash(a) != n.Hash(b) {
+				t.Errorf("%s: equal keys of length %d hash differently", n.Name, length)
+			}
+		}
+	}
+}
+
+func TestEveryByteMatters(t *testing.T) {
+	for _, n := range All {
+		for length := 1; length <= 40; length++ {
+			key := make([]byte, length)
+			base := n.Hash(key)
+			for i := range key {
+				if n.Name == "first8len" && i >= 8 {
+					// Only the first eight bytes are hashed
+					break
+				}
+				key[i] = 1
+				if n.Hash(key) == base {
+					t.Errorf("%s: changing byte %d of a %d byte key does not change the hash", n.Name, i, length)
+				}
+				key[i] = 0
+			}
+		}
+	}
+}
+
+func TestLoadTail(t *testing.T) {
+	for length := range 9 {
+		key := make([]byte, length)
+		words := map[uint64]int{loadTail(key): -1}
+		for i := range key {
+			key[i] = 0xff
+			w := loadTail(key)
+			if prev, ok := words[w]; ok {
+				t.Errorf("length %d: setting byte %d loads the same word as %d", length, i, prev)
+			}
+			words[w] = i
+			key[i] = 0
+		}
+	}
+}
@@ -0,0 +1,123 @@
+// Package lines splits measurements input into rows, the same way for every implementation:
+//
+//   - A UTF-8 byte order mark at the start of the input is skipped.
+//   - Rows end with "\n" or "\r\n", and the terminator is not part of the row.
+//   - The last row does not need a terminator.
+//
+// Scanner frames streams, Next and TrimBOM frame memory-mapped input.
+package lines
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"iter"
+)
+
+// BOM is the UTF-8 encoding of the byte order mark.
+var BOM = []byte{0xef, 0xbb, 0xbf}
+
+// ErrTooLong is returned by Scanner.Err if a row does not fit the scanner's buffer.
+var ErrTooLong = errors.New("lines: row longer than the buffer")
+
+// TrimBOM returns data without a leading byte order mark.
+func TrimBOM(data []byte) []byte {
+	return bytes.TrimPrefix(data, BOM)
+}
+
+// TrimCR returns line without a trailing '\r'.
+func TrimCR(line []byte) []byte {
+	if n := len(line); n > 0 && line[n-1] == '\r' {
+		return line[:n-1]
+	}
+	return line
+}
+
+// Next returns the first row of data without its terminator and the data after it.
+// If data has no '\n', the row is all of data and rest is empty.
+func Next(data []byte) (row, rest []byte) {
+	i := bytes.IndexByte(data, '\n')
+	if i < 0 {
+		return TrimCR(data), nil
+	}
+	return TrimCR(data[:i]), data[i+1:]
+}
+
+// Scanner reads the rows of a stream through a buffer of fixed size.
+type Scanner struct {
+	r    io.Reader
+	size int
+	err  error
+}
+
+// NewScanner returns a Scanner that reads r through a 1MB buffer.
+func NewScanner(r io.Reader) *Scanner {
+	return NewScannerSize(r, 1024*1024)
+}
+
+// NewScannerSize returns a Scanner that reads r through a buffer of size bytes,
+// which limits the length of a row.
+func NewScannerSize(r io.Reader, size int) *Scanner {
+	return &Scanner{r: r, size: max(size, len(BOM))}
+}
+
+// All yields the rows of the stream. A row is only valid until the next one is yielded.
+// Iteration stops early on a read error, see Err. All must only be ranged over once.
+func (s *Scanner) All() iter.Seq[[]byte] {
+	return func(yield func([]byte) bool) {
+		var (
+			buf   = make([]byte, s.size)
+			next  int    // Length of the incomplete row at the start of the buffer
+			start = true // Nothing has been yielded yet, so a BOM may still follow
+		)
+
+		for {
+			n, err := s.r.Read(buf[next:])
+			if err != nil && err != io.EOF {
+				s.err = err
+				return
+			}
+			data := buf[:next+n]
+
+			if start {
+				if len(data) < len(BOM) && bytes.HasPrefix(BOM, data) && err == nil {
+					// Too short to tell whether the input starts with a BOM
+					next = len(data)
+					continue
+				}
+				data = TrimBOM(data)
+				start = false
+			}
+
+			// Yield every complete row
+			last := bytes.LastIndexByte(data, '\n')
+			rows := data[:last+1]
+			for len(rows) > 0 {
+				var row []byte
+				row, rows = Next(rows)
+				if !yield(row) {
+					return
+				}
+			}
+
+			// Keep the incomplete row after the last newline for the next read
+			next = copy(buf, data[last+1:])
+			if err == io.EOF {
+				if next > 0 {
+					// The last row has no terminator
+					yield(TrimCR(buf[:next]))
+				}
+				return
+			}
+			if next == len(buf) {
+				s.err = ErrTooLong
+				return
+			}
+		}
+	}
+}
+
+// Err returns the first read error other than io.EOF that stopped All.
+func (s *Scanner) Err() error {
+	return s.err
+}
@@ -0,0 +1,112 @@
+package lines
+
+import (
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+var framingTests = []struct {
+	name  string
+	input string
+	want  []string
+}{
+	{"empty", "", nil},
+	{"lf", "A;1.0\nB;2.0\n", []string{"A;1.0", "B;2.0"}},
+	{"crlf", "A;1.0\r\nB;2.0\r\n", []string{"A;1.0", "B;2.0"}},
+	{"mixed", "A;1.0\r\nB;2.0\nC;3.0\r\n", []string{"A;1.0", "B;2.0", "C;3.0"}},
+	{"no trailing newline", "A;1.0\nB;2.0", []string{"A;1.0", "B;2.0"}},
+	{"crlf without trailing newline", "A;1.0\r\nB;2.0", []string{"A;1.0", "B;2.0"}},
+	{"trailing cr without newline", "A;1.0\r\nB;2.0\r", []string{"A;1.0", "B;2.0"}},
+	{"bom", "\xef\xbb\xbfA;1.0\nB;2.0\n", []string{"A;1.0", "B;2.0"}},
+	{"bom crlf without trailing newline", "\xef\xbb\xbfA;1.0\r\nB;2.0", []string{"A;1.0", "B;2.0"}},
+	{"bom only", "\xef\xbb\xbf", nil},
+	{"bom later is kept", "A;1.0\n\xef\xbb\xbfB;2.0\n", []string{"A;1.0", "\xef\xbb\xbfB;2.0"}},
+	{"partial bom is kept", "\xef\xbbA;1.0\n", []string{"\xef\xbbA;1.0"}},
+	{"empty rows are kept", "\n\r\nA;1.0\n", []string{"", "", "A;1.0"}},
+}
+
+func scanAll(t *testing.T, s *Scanner) []string {
+	t.Helper()
+	var rows []string
+	for row := range s.All() {
+		rows = append(rows, string(row))
+	}
+	if err := s.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+	return rows
+}
+
+func TestScanner(t *testing.T) {
+	for _, tt := range framingTests {
+		readers := map[string]io.Reader{
+			"whole":     strings.NewReader(tt.input),
+			"one byte":  iotest.OneByteReader(strings.NewReader(tt.input)),
+			"half":      iotest.HalfReader(strings.NewReader(tt.input)),
+			"data+EOF":  iotest.DataErrReader(strings.NewReader(tt.input)),
+			"small buf": nil,
+		}
+		for name, r := range readers {
+			s := NewScanner(r)
+			if r == nil {
+				// Rows are carried over between reads of a buffer not much longer than a row
+				s = NewScannerSize(strings.NewReader(tt.input), 10)
+			}
+			if got := scanAll(t, s); !slices.Equal(got, tt.want) {
+				t.Errorf("%s/%s: rows = %q, want %q", tt.name, name, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	for _, tt := range framingTests {
+		var got []string
+		data := TrimBOM([]byte(tt.input))
+		for len(data) > 0 {
+			var row []byte
+			row, data = Next(data)
+			got = append(got, string(row))
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: Next rows = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestScannerStopsEarly(t *testing.T) {
+	s := NewScanner(strings.NewReader("A;1.0\nB;2.0\nC;3.0\n"))
+	var rows int
+	for range s.All() {
+		rows++
+		if rows == 2 {
+			break
+		}
+	}
+	if rows != 2 {
+		t.Errorf("yielded %d rows after break, want 2", rows)
+	}
+}
+
+func TestScannerErrors(t *testing.T) {
+	readErr := errors.New("disk on fire")
+	s := NewScanner(io.MultiReader(strings.NewReader("A;1.0\nB;2"), iotest.ErrReader(readErr)))
+	var rows []string
+	for row := range s.All() {
+		rows = append(rows, string(row))
+	}
+	if !slices.Equal(rows, []string{"A;1.0"}) || s.Err() != readErr {
+		t.Errorf("rows = %q, Err() = %v; want [A;1.0] and %v", rows, s.Err(), readErr)
+	}
+
+	s = NewScannerSize(strings.NewReader("A;1.0\nLongStationName;2.0\n"), 8)
+	for range s.All() {
+	}
+	if s.Err() != ErrTooLong {
+		t.Errorf("Err() = %v, want ErrTooLong", s.Err())
+	}
+}
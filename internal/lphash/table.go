@@ -0,0 +1,184 @@
+// Package lphash implements a growable open-addressing hash table with linear probing,
+// keyed by byte slices, for aggregating measurements by station name.
+package lphash
+
+import (
+	"bytes"
+	"iter"
+	"math/bits"
+
+	"github.com/tyleryarnell/1brc/internal/keyhash"
+)
+
+// HashFunc hashes a key. Keys that are equal must have equal hashes.
+type HashFunc func(key []byte) uint64
+
+// Config configures a Table. The zero value is usable.
+type Config struct {
+	Capacity   int      // Initial number of slots, rounded up to a power of two (default 4096)
+	LoadFactor float64  // Grow once more than this fraction of the slots is in use, in (0, 1) (default 0.5)
+	Hash       HashFunc // Hash function for keys, see package keyhash (default keyhash.FNV1a)
+}
+
+// Table maps byte slice keys to values of type V. It copies keys on insertion, so callers
+// may reuse their key buffers, and doubles its number of slots when it gets too full.
+// A Table is not safe for concurrent use.
+type Table[V any] struct {
+	slots      []slot[V]
+	mask       uint64
+	len        int
+	growAt     int
+	loadFactor float64
+	hash       HashFunc // nil for keyhash.FNV1a, which is called directly rather than through a func value
+}
+
+type slot[V any] struct {
+	hash  uint64
+	key   []byte // nil for an empty slot
+	value V
+}
+
+// New returns an empty table configured by cfg.
+func New[V any](cfg Config) *Table[V] {
+	t := &Table[V]{loadFactor: cfg.LoadFactor, hash: cfg.Hash}
+	if t.loadFactor <= 0 || t.loadFactor >= 1 {
+		t.loadFactor = 0.5
+	}
+	capacity := cfg.Capacity
+	if capacity <= 0 {
+		capacity = 4096
+	}
+	t.resize(1 << bits.Len(uint(capacity-1)))
+	return t
+}
+
+// Len returns the number of keys in the table.
+func (t *Table[V]) Len() int {
+	return t.len
+}
+
+// Cap returns the number of slots in the table.
+func (t *Table[V]) Cap() int {
+	return len(t.slots)
+}
+
+// hashKey hashes key with the configured hash function.
+func (t *Table[V]) hashKey(key []byte) uint64 {
+	if t.hash == nil {
+		return keyhash.FNV1a(key)
+	}
+	return t.hash(key)
+}
+
+// Get returns a pointer to the value of key, or nil if key is not in the table.
+// The pointer is valid until the next insertion.
+func (t *Table[V]) Get(key []byte) *V {
+	hash := t.hashKey(key)
+	for i := hash & t.mask; ; i = (i + 1) & t.mask {
+		s := &t.slots[i]
+		if s.key == nil {
+			return nil
+		}
+		if s.hash == hash && bytes.Equal(s.key, key) {
+			return &s.value
+		}
+	}
+}
+
+// Upsert returns a pointer to the value of key, inserting the zero value first if key is
+// not in the table yet, and reports whether it was inserted. The pointer is valid until the
+// next insertion.
+func (t *Table[V]) Upsert(key []byte) (value *V, inserted bool) {
+	var hash uint64
+	if t.hash == nil {
+		hash = keyhash.FNV1a(key)
+	} else {
+		hash = t.hash(key)
+	}
+	return t.UpsertHash(key, hash)
+}
+
+// UpsertHash is like Upsert for a key whose hash the caller has already computed, e.g. while
+// scanning for the end of the key. hash must equal what the configured hash function returns
+// for key, otherwise Get and Upsert will not find the entry.
+func (t *Table[V]) UpsertHash(key []byte, hash uint64) (value *V, inserted bool) {
+	slots, mask := t.slots, t.mask
+	for i := hash & mask; ; i = (i + 1) & mask {
+		s := &slots[i]
+		if s.key == nil {
+			return t.insert(s, hash, key), true
+		}
+		if s.hash == hash && bytes.Equal(s.key, key) {
+			return &s.value, false
+		}
+	}
+}
+
+// insert stores key in the empty slot s, or grows the table first if it is too full.
+func (t *Table[V]) insert(s *slot[V], hash uint64, key []byte) *V {
+	if t.len >= t.growAt {
+		t.resize(2 * len(t.slots))
+		i := hash & t.mask
+		for t.slots[i].key != nil {
+			i = (i + 1) & t.mask
+		}
+		s = &t.slots[i]
+	}
+	s.hash = hash
+	s.key = append(make([]byte, 0, len(key)), key...)
+	t.len++
+	return &s.value
+}
+
+// All yields every key and a pointer to its value, in no particular order.
+// The table must not be modified during iteration.
+func (t *Table[V]) All() iter.Seq2[[]byte, *V] {
+	return func(yield func([]byte, *V) bool) {
+		for i := range t.slots {
+			s := &t.slots[i]
+			if s.key != nil && !yield(s.key, &s.value) {
+				return
+			}
+		}
+	}
+}
+
+// resize moves every entry into a new slot array of size slots, a power of two.
+func (t *Table[V]) resize(size int) {
+	old := t.slots
+	t.slots = make([]slot[V], size)
+	t.mask = uint64(size - 1)
+	// Always leave at least one empty slot so that probing terminates
+	t.growAt = min(int(float64(size)*t.loadFactor), size-1)
+
+	for i := range old {
+		s := &old[i]
+		if s.key == nil {
+			continue
+		}
+		j := s.hash & t.mask
+		for t.slots[j].key != nil {
+			j = (j + 1) & t.mask
+		}
+		t.slots[j] = *s
+	}
+}
+
+// Stats holds the aggregated measurements of a station in tenths of a degree.
+type Stats struct {
+	Min, Max int32
+	Sum      int64
+	Count    int64
+}
+
+// Add records a measurement. The zero Stats has no measurements.
+func (s *Stats) Add(temp int32) {
+	if s.Count == 0 {
+		s.Min, s.Max = temp, temp
+	} else {
+		s.Min = min(s.Min, temp)
+		s.Max = max(s.Max, temp)
+	}
+	s.Sum += int64(temp)
+	s.Count++
+}
@@ -0,0 +1,190 @@
+package lphash
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/tyleryarnell/1brc/internal/keyhash"
+)
+
+func keys(n int) [][]byte {
+	keys := make([][]byte, n)
+	for i := range keys {
+		keys[i] = fmt.Appendf(nil, "station-%d", i)
+	}
+	return keys
+}
+
+func TestTableMatchesMap(t *testing.T) {
+	configs := map[string]Config{
+		"default":         {},
+		"tiny":            {Capacity: 1},
+		"high load":       {Capacity: 16, LoadFactor: 0.95},
+		"constant hash":   {Hash: func([]byte) uint64 { return 42 }},
+		"invalid factors": {Capacity: -5, LoadFactor: 3},
+	}
+	for name, cfg := range configs {
+		t.Run(name, func(t *testing.T) {
+			n := 100_000
+			if name == "constant hash" {
+				// Every key collides, keep the quadratic probing cost down
+				n = 2000
+			}
+			rng := rand.New(rand.NewSource(1))
+			ks := keys(n)
+			want := make(map[string]int)
+			table := New[int](cfg)
+
+			buf := make([]byte, 0, 32)
+			for range 3 * n {
+				key := ks[rng.Intn(n)]
+				// Reuse the key buffer to check that the table copies keys
+				buf = append(buf[:0], key...)
+				v, inserted := table.Upsert(buf)
+				if _, ok := want[string(key)]; ok == inserted {
+					t.Fatalf("Upsert(%s) inserted = %v, want %v", key, inserted, !ok)
+				}
+				*v++
+				want[string(key)]++
+			}
+
+			if table.Len() != len(want) {
+				t.Errorf("Len() = %d, want %d", table.Len(), len(want))
+			}
+			if table.Len() > int(float64(table.Cap())*max(cfg.LoadFactor, 0.5)) && cfg.LoadFactor < 1 {
+				t.Errorf("Len() = %d exceeds the load factor of Cap() = %d", table.Len(), table.Cap())
+			}
+			for key, count := range want {
+				if v := table.Get([]byte(key)); v == nil || *v != count {
+					t.Errorf("Get(%s) = %v, want %d", key, v, count)
+				}
+			}
+			if v := table.Get([]byte("missing")); v != nil {
+				t.Errorf("Get(missing) = %d, want nil", *v)
+			}
+
+			seen := 0
+			for key, v := range table.All() {
+				seen++
+				if *v != want[string(key)] {
+					t.Errorf("All() yielded %s = %d, want %d", key, *v, want[string(key)])
+				}
+			}
+			if seen != len(want) {
+				t.Errorf("All() yielded %d keys, want %d", seen, len(want))
+			}
+		})
+	}
+}
+
+func TestTableAllStops(t *testing.T) {
+	table := New[int](Config{})
+	for _, key := range keys(10) {
+		table.Upsert(key)
+	}
+	n := 0
+	for range table.All() {
+		n++
+		if n == 3 {
+			break
+		}
+	}
+	if n != 3 {
+		t.Errorf("All() yielded %d keys after break, want 3", n)
+	}
+}
+
+func TestEmptyKey(t *testing.T) {
+	table := New[int](Config{})
+	v, inserted := table.Upsert(nil)
+	*v = 7
+	if !inserted || table.Len() != 1 {
+		t.Fatalf("Upsert(nil) inserted = %v, Len() = %d", inserted, table.Len())
+	}
+	if v := table.Get([]byte{}); v == nil || *v != 7 {
+		t.Errorf("Get(empty) = %v, want 7", v)
+	}
+}
+
+func TestUpsertHash(t *testing.T) {
+	table := New[int](Config{Hash: keyhash.Words, Capacity: 2})
+	for i, key := range keys(100) {
+		v, inserted := table.UpsertHash(key, keyhash.Words(key))
+		if !inserted {
+			t.Fatalf("UpsertHash(%q) did not insert a new key", key)
+		}
+		*v = i
+	}
+	for i, key := range keys(100) {
+		if v := table.Get(key); v == nil || *v != i {
+			t.Errorf("Get(%q) = %v, want %d", key, v, i)
+		}
+	}
+}
+
+func TestStatsAdd(t *testing.T) {
+	var s Stats
+	for _, temp := range []int32{50, -999, 999, 0} {
+		s.Add(temp)
+	}
+	if want := (Stats{Min: -999, Max: 999, Sum: 50, Count: 4}); s != want {
+		t.Errorf("Stats = %+v, want %+v", s, want)
+	}
+
+	var neg Stats
+	neg.Add(-5)
+	if neg.Max != -5 {
+		t.Errorf("Max after a single negative measurement = %d, want -5", neg.Max)
+	}
+}
+
+func benchmarkUpsert(b *testing.B, stations int, cfg Config) {
+	ks := keys(stations)
+	rng := rand.New(rand.NewSource(1))
+	order := make([]int, 1<<16)
+	for i := range order {
+		order[i] = rng.Intn(stations)
+	}
+	table := New[Stats](cfg)
+	b.ResetTimer()
+	for i := range b.N {
+		s, _ := table.Upsert(ks[order[i&(len(order)-1)]])
+		s.Add(int32(i & 1023))
+	}
+}
+
+func BenchmarkUpsert(b *testing.B) {
+	for _, stations := range []int{413, 10_000, 100_000} {
+		b.Run(fmt.Sprintf("%d stations", stations), func(b *testing.B) {
+			benchmarkUpsert(b, stations, Config{})
+		})
+		b.Run(fmt.Sprintf("%d stations/presized", stations), func(b *testing.B) {
+			benchmarkUpsert(b, stations, Config{Capacity: 4 * stations})
+		})
+	}
+}
+
+func BenchmarkMap(b *testing.B) {
+	for _, stations := range []int{413, 10_000, 100_000} {
+		b.Run(fmt.Sprintf("%d stations", stations), func(b *testing.B) {
+			ks := keys(stations)
+			rng := rand.New(rand.NewSource(1))
+			order := make([]int, 1<<16)
+			for i := range order {
+				order[i] = rng.Intn(stations)
+			}
+			m := make(map[string]*Stats)
+			b.ResetTimer()
+			for i := range b.N {
+				key := ks[order[i&(len(order)-1)]]
+				s := m[string(key)]
+				if s == nil {
+					s = &Stats{}
+					m[string(key)] = s
+				}
+				s.Add(int32(i & 1023))
+			}
+		})
+	}
+}
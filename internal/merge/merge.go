@@ -0,0 +1,133 @@
+// Package merge combines the per-worker hash tables of the parallel implementations into
+// sorted results.
+package merge
+
+import (
+	"hash/maphash"
+	"iter"
+	"runtime"
+	"sync"
+
+	obrc "github.com/tyleryarnell/1brc"
+	"github.com/tyleryarnell/1brc/internal/lphash"
+)
+
+// Entry is the aggregate of one station in a worker's table, in tenths of a degree.
+type Entry struct {
+	Key                  []byte
+	Min, Max, Sum, Count int64
+}
+
+// Tables merges the per-worker tables into sorted results without funnelling them through
+// a single goroutine. Each table is first scattered by the hash of its keys into one bucket
+// per partition, concurrently for all tables. Then every partition merges its buckets from
+// all tables concurrently, so a station is only ever merged by one goroutine and no locks
+// are needed. Station strings are allocated once, for the final results.
+//
+// partitions <= 0 uses one partition per core. Keys only need to stay valid until Tables returns.
+func Tables(tables []iter.Seq[Entry], partitions int) obrc.Results {
+	if partitions <= 0 {
+		partitions = runtime.GOMAXPROCS(0)
+	}
+	if partitions == 1 {
+		// Nothing to merge in parallel, skip the scatter
+		results := mergePartition(func(yield func(Entry) bool) {
+			for _, table := range tables {
+				for e := range table {
+					if !yield(e) {
+						return
+					}
+				}
+			}
+		}, 0)
+		results.Sort()
+		return results
+	}
+	seed := maphash.MakeSeed()
+
+	// Scatter: buckets[t][p] holds the entries of table t that belong to partition p
+	buckets := make([][][]Entry, len(tables))
+	wg := sync.WaitGroup{}
+	for t, table := range tables {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b := make([][]Entry, partitions)
+			for e := range table {
+				p := maphash.Bytes(seed, e.Key) % uint64(partitions)
+				b[p] = append(b[p], e)
+			}
+			buckets[t] = b
+		}()
+	}
+	wg.Wait()
+
+	// Merge each partition on its own goroutine
+	merged := make([]obrc.Results, partitions)
+	for p := range partitions {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Every table usually holds most stations, so the largest bucket estimates the partition size
+			size := 0
+			for _, b := range buckets {
+				size = max(size, len(b[p]))
+			}
+			merged[p] = mergePartition(func(yield func(Entry) bool) {
+				for _, b := range buckets {
+					for _, e := range b[p] {
+						if !yield(e) {
+							return
+						}
+					}
+				}
+			}, size)
+		}()
+	}
+	wg.Wait()
+
+	// Partitions hold disjoint stations, so they only need to be concatenated and sorted
+	n := 0
+	for _, part := range merged {
+		n += len(part)
+	}
+	results := make(obrc.Results, 0, n)
+	for _, part := range merged {
+		results = append(results, part...)
+	}
+	results.Sort()
+	return results
+}
+
+// mergePartition merges entries that may repeat stations into sorted results. size estimates
+// the number of distinct stations.
+func mergePartition(entries iter.Seq[Entry], size int) obrc.Results {
+	results := make(obrc.Results, 0, size)
+	index := make(map[string]int, size)
+	for e := range entries {
+		// Looking up a []byte converted to string does not allocate
+		if i, ok := index[string(e.Key)]; ok {
+			r := &results[i]
+			r.Min = min(r.Min, e.Min)
+			r.Max = max(r.Max, e.Max)
+			r.Sum += e.Sum
+			r.Count += e.Count
+			continue
+		}
+		station := string(e.Key)
+		index[station] = len(results)
+		results = append(results, obrc.StationResult{Station: station, Min: e.Min, Max: e.Max, Sum: e.Sum, Count: e.Count})
+	}
+	return results
+}
+
+// FromTable yields the stations of a worker's table as entries.
+func FromTable(table *lphash.Table[lphash.Stats]) iter.Seq[Entry] {
+	return func(yield func(Entry) bool) {
+		for key, s := range table.All() {
+			if !yield(Entry{Key: key, Min: int64(s.Min), Max: int64(s.Max), Sum: s.Sum, Count: s.Count}) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package merge
+
+import (
+	"fmt"
+	"iter"
+	"math/rand"
+	"slices"
+	"testing"
+
+	obrc "github.com/tyleryarnell/1brc"
+)
+
+// randomTables returns n tables over overlapping subsets of stations, both as entries and as results.
+func randomTables(rng *rand.Rand, n, stations int) ([][]Entry, []obrc.Results) {
+	tables := make([][]Entry, n)
+	parts := make([]obrc.Results, n)
+	for t := range tables {
+		for s := range stations {
+			if rng.Intn(3) == 0 {
+				continue
+			}
+			lo, hi := int64(rng.Intn(1999)-999), int64(rng.Intn(1999)-999)
+			e := Entry{Key: fmt.Appendf(nil, "station-%d", s), Min: min(lo, hi), Max: max(lo, hi), Sum: lo + hi, Count: 2}
+			tables[t] = append(tables[t], e)
+			parts[t] = append(parts[t], obrc.StationResult{Station: string(e.Key), Min: e.Min, Max: e.Max, Sum: e.Sum, Count: e.Count})
+		}
+	}
+	return tables, parts
+}
+
+func seqs(tables [][]Entry) []iter.Seq[Entry] {
+	s := make([]iter.Seq[Entry], len(tables))
+	for i, t := range tables {
+		s[i] = slices.Values(t)
+	}
+	return s
+}
+
+func TestTables(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, n := range []int{0, 1, 2, 7} {
+		tables, parts := randomTables(rng, n, 500)
+		want := obrc.Merge(parts...)
+		for _, partitions := range []int{0, 1, 3, 16} {
+			got := Tables(seqs(tables), partitions)
+			if !slices.Equal(got, want) {
+				t.Errorf("Tables(%d tables, %d partitions) = %.200v, want %.200v", n, partitions, got, want)
+			}
+		}
+	}
+}
+
+func TestTablesDoesNotRetainKeys(t *testing.T) {
+	key := []byte("Oslo")
+	results := Tables([]iter.Seq[Entry]{slices.Values([]Entry{{Key: key, Min: 1, Max: 1, Sum: 1, Count: 1}})}, 2)
+	copy(key, "Rome")
+	if results[0].Station != "Oslo" {
+		t.Errorf("station = %q after reusing the key buffer, want Oslo", results[0].Station)
+	}
+}
+
+func benchmarkTables(b *testing.B, merge func([][]Entry) obrc.Results) {
+	tables, _ := randomTables(rand.New(rand.NewSource(2)), 64, 10_000)
+	b.ResetTimer()
+	for range b.N {
+		merge(tables)
+	}
+}
+
+func BenchmarkTables(b *testing.B) {
+	benchmarkTables(b, func(tables [][]Entry) obrc.Results {
+		return Tables(seqs(tables), 0)
+	})
+}
+
+// BenchmarkChannel merges like r08 did before Tables, sending every entry over one channel to a
+// single goroutine that merges them into a map.
+func BenchmarkChannel(b *testing.B) {
+	benchmarkTables(b, func(tables [][]Entry) obrc.Results {
+		ch := make(chan Entry, len(tables))
+		for _, t := range tables {
+			go func() {
+				for _, e := range t {
+					ch <- e
+				}
+			}()
+		}
+
+		total := 0
+		for _, t := range tables {
+			total += len(t)
+		}
+		m := make(map[string]*obrc.StationResult)
+		for range total {
+			e := <-ch
+			r := m[string(e.Key)]
+			if r == nil {
+				m[string(e.Key)] = &obrc.StationResult{Station: string(e.Key), Min: e.Min, Max: e.Max, Sum: e.Sum, Count: e.Count}
+				continue
+			}
+			r.Min, r.Max = min(r.Min, e.Min), max(r.Max, e.Max)
+			r.Sum += e.Sum
+			r.Count += e.Count
+		}
+		results := make(obrc.Results, 0, len(m))
+		for _, r := range m {
+			results = append(results, *r)
+		}
+		results.Sort()
+		return results
+	})
+}
@@ -2,45 +2,51 @@ package one
 
 import (
 	"bufio"
-	"fmt"
 	"io"
 	"iter"
 	"os"
 	"sort"
 	"strconv"
 	"strings"
+
+	obrc "github.com/tyleryarnell/1brc"
+	"github.com/tyleryarnell/1brc/internal/lines"
 )
 
 // Calculate reads the input and calculates the min, average, and max values for each station
-func Calculate(inputFile string, output io.Writer) error {
+func Calculate(inputFile string) (obrc.Results, error) {
 
 	// Open the file to be processed
 	file, err := os.Open(inputFile)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer file.Close()
 
+	return CalculateReader(file)
+}
+
+// CalculateReader reads measurements from input and calculates the min, average, and max values for each station
+func CalculateReader(input io.Reader) (obrc.Results, error) {
 	type stats struct {
 		min, max, sum float64
 		count         int
 	}
 
 	measurements := make(map[string]stats)
-	cnt := 0
-	for line := range getMeasurements(file) {
+	scanner := bufio.NewScanner(input)
+	for line := range getMeasurements(scanner) {
 		parts := strings.Split(line, ";")
 		if len(parts) != 2 {
-			panic(fmt.Sprintf("Malformed line: %q", line))
+			return nil, obrc.MalformedRowError([]byte(line))
 		}
 
 		station := parts[0]
 		value, err := strconv.ParseFloat(parts[1], 64)
-		if err != nil {
-			panic(fmt.Sprintf("Failed to parse value %q: %v", parts[1], err))
+		if err != nil || !obrc.IsTemperature(parts[1]) {
+			return nil, obrc.MalformedRowError([]byte(line))
 		}
 
-		cnt++
 		if _, exists := measurements[station]; !exists {
 			measurements[station] = stats{min: value, max: value, sum: value, count: 1}
 			continue
@@ -55,7 +61,10 @@ func Calculate(inputFile string, output io.Writer) error {
 		measurements[station] = m
 	}
 
-	fmt.Println("cnt", cnt)
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
 	// Sort the station names
 	sortedKeys := make([]string, 0, len(measurements))
 	for key := range measurements {
@@ -63,38 +72,36 @@ func Calculate(inputFile string, output io.Writer) error {
 	}
 	sort.Strings(sortedKeys)
 
-	// Write results to the output writer
-	var builder strings.Builder
-	builder.WriteString("{")
-	for i, station := range sortedKeys {
-		res := fmt.Sprintf("%s=%.1f/%.1f/%.1f", station,
-			measurements[station].min,
-			measurements[station].sum/float64(measurements[station].count),
-			measurements[station].max)
-		builder.WriteString(res)
-		if i < len(sortedKeys)-1 {
-			builder.WriteString(", ")
-		}
-	}
-	builder.WriteString("}\n")
-	if _, err := output.Write([]byte(builder.String())); err != nil {
-		return fmt.Errorf("Failed to write results to output: %v", err)
+	// Collect the results in station order
+	results := make(obrc.Results, 0, len(sortedKeys))
+	for _, station := range sortedKeys {
+		m := measurements[station]
+		results = append(results, obrc.StationResult{
+			Station: station,
+			Min:     obrc.ToTenths(m.min),
+			Max:     obrc.ToTenths(m.max),
+			Sum:     obrc.ToTenths(m.sum),
+			Count:   int64(m.count),
+		})
 	}
 
-	return nil
+	return results, nil
 }
 
-func getMeasurements(inp io.Reader) iter.Seq[string] {
-	scanner := bufio.NewScanner(inp)
+// getMeasurements yields the rows of scanner as strings, dropping the byte order mark at the
+// start of the input. bufio.ScanLines already drops the '\r' of a "\r\n" terminator and
+// yields the last row without a terminator. A read error stops the iteration, see scanner.Err.
+func getMeasurements(scanner *bufio.Scanner) iter.Seq[string] {
 	return func(yield func(string) bool) {
+		first := true
 		for scanner.Scan() {
-			if !yield(scanner.Text()) {
+			row := scanner.Bytes()
+			if first {
+				row, first = lines.TrimBOM(row), false
+			}
+			if !yield(string(row)) {
 				return
 			}
 		}
-		if err := scanner.Err(); err != nil {
-			// Handle the error as needed
-			return
-		}
 	}
 }
@@ -0,0 +1,13 @@
+package one
+
+import obrc "github.com/tyleryarnell/1brc"
+
+func init() {
+	obrc.Register(obrc.Implementation{
+		Version:     1,
+		Name:        "r01",
+		Description: "iterator",
+		Tags:        []string{"scanner", "iterator", "stream"},
+		Calculator:  obrc.StreamFunc(CalculateReader),
+	})
+}
@@ -0,0 +1,13 @@
+package two
+
+import obrc "github.com/tyleryarnell/1brc"
+
+func init() {
+	obrc.Register(obrc.Implementation{
+		Version:     2,
+		Name:        "r02",
+		Description: "buffered reader",
+		Tags:        []string{"buffered", "iterator", "stream"},
+		Calculator:  obrc.StreamFunc(CalculateReader),
+	})
+}
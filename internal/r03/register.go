@@ -0,0 +1,13 @@
+package three
+
+import obrc "github.com/tyleryarnell/1brc"
+
+func init() {
+	obrc.Register(obrc.Implementation{
+		Version:     3,
+		Name:        "r03",
+		Description: "map assigns",
+		Tags:        []string{"buffered", "iterator", "stream"},
+		Calculator:  obrc.StreamFunc(CalculateReader),
+	})
+}
@@ -0,0 +1,13 @@
+package four
+
+import obrc "github.com/tyleryarnell/1brc"
+
+func init() {
+	obrc.Register(obrc.Implementation{
+		Version:     4,
+		Name:        "r04",
+		Description: "parse as bytes",
+		Tags:        []string{"buffered", "bytes", "stream"},
+		Calculator:  obrc.StreamFunc(CalculateReader),
+	})
+}
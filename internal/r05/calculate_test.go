@@ -11,29 +11,40 @@ func Test_parseRow(t *testing.T) {
 		args  args
 		want  string
 		want1 float64
+		want2 bool
 	}{
 		{
 			name:  "positive",
 			args:  args{row: []byte("Bamako;36.9")},
 			want:  "Bamako",
 			want1: 36.9,
+			want2: true,
 		},
 		{
 			name:  "negative",
 			args:  args{row: []byte("Ulaanbaatar;-1.3")},
 			want:  "Ulaanbaatar",
 			want1: -1.3,
+			want2: true,
 		},
+		{name: "empty", args: args{row: []byte("")}},
+		{name: "empty station", args: args{row: []byte(";1.0")}},
+		{name: "missing semicolon", args: args{row: []byte("Bamako 36.9")}},
+		{name: "missing fraction", args: args{row: []byte("Bamako;36")}},
+		{name: "not a number", args: args{row: []byte("Bamako;a.b")}},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, got1 := parseRow(tt.args.row)
+			got, got1, got2 := parseRow(tt.args.row)
 			if got != tt.want {
 				t.Errorf("parseRow() got = %v, want %v", got, tt.want)
 			}
 			if got1 != tt.want1 {
 				t.Errorf("parseRow() got1 = %v, want %v", got1, tt.want1)
 			}
+			if got2 != tt.want2 {
+				t.Errorf("parseRow() got2 = %v, want %v", got2, tt.want2)
+			}
 		})
 	}
 }
@@ -0,0 +1,13 @@
+package five
+
+import obrc "github.com/tyleryarnell/1brc"
+
+func init() {
+	obrc.Register(obrc.Implementation{
+		Version:     5,
+		Name:        "r05",
+		Description: "improved bytes parsing",
+		Tags:        []string{"buffered", "bytes", "stream"},
+		Calculator:  obrc.StreamFunc(CalculateReader),
+	})
+}
@@ -0,0 +1,13 @@
+package six
+
+import obrc "github.com/tyleryarnell/1brc"
+
+func init() {
+	obrc.Register(obrc.Implementation{
+		Version:     6,
+		Name:        "r06",
+		Description: "byte parsing and int conversion",
+		Tags:        []string{"buffered", "bytes", "int", "stream"},
+		Calculator:  obrc.StreamFunc(CalculateReader),
+	})
+}
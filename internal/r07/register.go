@@ -0,0 +1,13 @@
+package seven
+
+import obrc "github.com/tyleryarnell/1brc"
+
+func init() {
+	obrc.Register(obrc.Implementation{
+		Version:     7,
+		Name:        "r07",
+		Description: "custom hash table",
+		Tags:        []string{"buffered", "bytes", "int", "hashtable", "stream"},
+		Calculator:  obrc.StreamFunc(CalculateReader),
+	})
+}
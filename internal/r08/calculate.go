@@ -2,161 +2,149 @@ package eight
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"iter"
 	"os"
 	"runtime"
-	"sort"
-	"strings"
 	"sync"
 	"syscall"
+
+	obrc "github.com/tyleryarnell/1brc"
+	"github.com/tyleryarnell/1brc/internal/chunk"
+	"github.com/tyleryarnell/1brc/internal/lines"
+	"github.com/tyleryarnell/1brc/internal/lphash"
+	"github.com/tyleryarnell/1brc/internal/merge"
 )
 
 // Calculate reads the input and calculates the min, average, and max values for each station
-func Calculate(inputFile string, output io.Writer) error {
+func Calculate(inputFile string) (obrc.Results, error) {
+	return CalculateContext(context.Background(), inputFile)
+}
+
+// CalculateContext is like Calculate but stops all chunk goroutines and returns ctx.Err()
+// once ctx is done. Each goroutine checks ctx before reading the next block of its chunk.
+// If ctx asks to skip malformed rows, see obrc.WithSkipMalformed, every row is validated instead.
+func CalculateContext(ctx context.Context, inputFile string) (obrc.Results, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	// Open the file to be processed
 	file, err := os.Open(inputFile)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer file.Close()
 
 	// Get file size
 	fileInfo, err := file.Stat()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	fileSize := fileInfo.Size()
+	if fileSize == 0 {
+		// Nothing to map
+		return obrc.Results{}, nil
+	}
 
 	// Memory map the file
 	data, err := syscall.Mmap(int(file.Fd()), 0, int(fileSize), syscall.PROT_READ, syscall.MAP_SHARED)
 	if err != nil {
-		return fmt.Errorf("failed to memory-map the file: %v", err)
+		return nil, fmt.Errorf("failed to memory-map the file: %v", err)
 	}
 	defer syscall.Munmap(data)
 
 	// Determine the number of available CPU cores
 	numCores := runtime.GOMAXPROCS(0)
 
-	chunkSize := fileSize / 100
-
-	wg := sync.WaitGroup{}
-	resultChan := make(chan result, numCores)
+	chunks := chunk.Plan(data, numCores)
+	tables := make([]*lphash.Table[lphash.Stats], len(chunks))
 
-	// Initialize start at the beginning of the file
-	start := int64(0)
+	// A malformed row in one chunk stops the goroutines of the others, unless ctx asks to skip them
+	chunkCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	report, skipMalformed := obrc.SkipMalformed(ctx)
+	skipped := make([]int64, len(chunks))
 
-	// Process each chunk in parallel, ensuring continuous chunking
-	for i := 0; i < numCores; i++ {
-		// Calculate the end of the current chunk
-		end := start + chunkSize
-
-		// Adjust the end to the next newline to ensure we end at a line boundary
-		if end < fileSize {
-			for end < fileSize && data[end] != '\n' {
-				end++
-			}
-			end++ // Move to the first character after the newline
-		}
-
-		// Increment the wait group
+	// Process one line-aligned chunk per core in parallel, together the chunks cover the whole file
+	wg := sync.WaitGroup{}
+	for i, c := range chunks {
 		wg.Add(1)
-
-		// Process the chunk in a goroutine
-		go func(start, end int64) {
+		go func() {
 			defer wg.Done()
-
-			// Create a reader for the chunk and process it
-			chunk := data[start:end]
-			processChunk(bytes.NewReader(chunk), resultChan)
-
-		}(start, end)
-
-		// Move start to the end of this chunk for the next iteration
-		start = end
-
-		// If we've processed the whole file, break the loop
-		if start >= fileSize {
-			break
-		}
+			table, n, err := processChunk(chunkCtx, bytes.NewReader(data[c.Start:c.End]), skipMalformed)
+			if err != nil {
+				cancel(err)
+			}
+			tables[i], skipped[i] = table, n
+		}()
 	}
+	wg.Wait()
 
-	go func() {
-		wg.Wait()
-		close(resultChan)
-	}()
-
-	measurements := make(map[string]*stats)
-	// Merge the results
-	for res := range resultChan {
-		station, stats := string(res.station), res.stats
-		s := measurements[station]
-		if s == nil {
-			measurements[station] = stats
-		} else {
-			s.min = min(s.min, stats.min)
-			s.max = max(s.max, stats.max)
-			s.sum += stats.sum
-			s.count += stats.count
-		}
+	// All goroutines have finished, report cancellation or the first error instead of partial results
+	if err := context.Cause(chunkCtx); err != nil {
+		return nil, err
 	}
 
-	// Sort the station names
-	sortedKeys := make([]string, 0, len(measurements))
-	for key := range measurements {
-		sortedKeys = append(sortedKeys, key)
-	}
-	sort.Strings(sortedKeys)
-
-	// Write results to the output writer
-	var builder strings.Builder
-	builder.WriteString("{")
-	for i, station := range sortedKeys {
-		res := fmt.Sprintf("%s=%.1f/%.1f/%.1f", station,
-			float64(measurements[station].min/10),
-			float64(measurements[station].sum)/float64(measurements[station].count)/10,
-			float64(measurements[station].max)/10)
-		builder.WriteString(res)
-		if i < len(sortedKeys)-1 {
-			builder.WriteString(", ")
+	if skipMalformed {
+		var total int64
+		for _, n := range skipped {
+			total += n
 		}
+		report(total)
 	}
-	builder.WriteString("}\n")
-	if _, err := output.Write([]byte(builder.String())); err != nil {
-		return fmt.Errorf("Failed to write results to output: %v", err)
-	}
-
-	return nil
-}
 
-type result struct {
-	station []byte
-	stats   *stats
+	// Merge the per-chunk tables
+	entries := make([]iter.Seq[merge.Entry], len(tables))
+	for i, table := range tables {
+		entries[i] = merge.FromTable(table)
+	}
+	return merge.Tables(entries, numCores), nil
 }
 
-func processChunk(inp io.Reader, out chan<- result) {
-	hashTable := newHashTable()
+// processChunk aggregates the rows read from inp. With skipMalformed it validates every row
+// with obrc.ValidateRow and skips the malformed ones, returning how many it skipped.
+func processChunk(ctx context.Context, inp io.Reader, skipMalformed bool) (*lphash.Table[lphash.Stats], int64, error) {
+	hashTable := lphash.New[lphash.Stats](lphash.Config{})
 
-	for line := range getMeasurements(inp) {
-		station, value := parseRow(line)
+	var skipped int64
+	rows := lines.NewScanner(obrc.NewContextReader(ctx, inp))
+	for line := range rows.All() {
+		var (
+			station []byte
+			value   int32
+			ok      bool
+			err     error
+		)
+		if skipMalformed {
+			if station, value, err = obrc.ValidateRow(line); err != nil {
+				skipped++
+				continue
+			}
+		} else if station, value, ok = parseRow(line); !ok {
+			return nil, 0, obrc.MalformedRowError(line)
+		}
 
 		// Insert or update hash table
-		hashTable.insertOrUpdate(station, value)
+		s, _ := hashTable.Upsert(station)
+		s.Add(value)
 	}
 
-	for station, stats := range hashTable.All() {
-		out <- result{station, stats}
-	}
+	return hashTable, skipped, rows.Err()
 }
 
-// parse row backwards
-func parseRow(row []byte) ([]byte, int32) {
+// parse row backwards, returning false if the row cannot be parsed
+func parseRow(row []byte) ([]byte, int32, bool) {
 	// Find the last comma in the row
 
 	// parse backwards according to
 	// Temperature value: non null double between -99.9 (inclusive) and 99.9 (inclusive), always with one fractional digit
+	// The shortest row is "X;0.0", and the dot must be surrounded by digits
+	if len(row) < 5 || row[len(row)-2] != '.' || row[len(row)-1]-'0' > 9 || row[len(row)-3]-'0' > 9 {
+		return nil, 0, false
+	}
 	nRow := len(row) - 1 // last index
 	temp := int32(row[nRow] - '0')
 
@@ -175,68 +163,10 @@ func parseRow(row []byte) ([]byte, int32) {
 		nRow--
 	}
 
-	nRow-- // skip the semicolon
-
-	return row[:nRow+1], temp
-}
-
-func getMeasurements(inp io.Reader) iter.Seq[[]byte] {
-	return func(yield func([]byte) bool) {
-		var (
-			buf  = make([]byte, 1024*1024) // 1MB buffer
-			next int                       // Next start position in the buffer
-		)
-
-		// Read the file in chunks
-		for {
-			// Read into the buffer starting from 'next'
-			n, err := inp.Read(buf[next:])
-			if err != nil && err != io.EOF {
-				return
-			}
-			if n == 0 && next == 0 {
-				break
-			}
-
-			// Only process the valid portion of the buffer
-			validBuf := buf[:next+n]
-
-			// Find the last newline in the buffer
-			lastNewLineIdx := bytes.LastIndexByte(validBuf, '\n')
-
-			if lastNewLineIdx == -1 {
-				// No newline found, continue reading more data
-				next = len(validBuf)
-				if next == cap(buf) {
-					// If the buffer is full without any newlines, there's an error
-					panic("line exceeds buffer size")
-				}
-				continue
-			}
-
-			// Process each line in the chunk up to the last newline
-			chunk := validBuf[:lastNewLineIdx+1]
-			remainder := validBuf[lastNewLineIdx+1:]
-
-			for {
-				nextLine := bytes.IndexByte(chunk, '\n')
-				if nextLine == -1 {
-					break
-				}
-				line := chunk[:nextLine]
-				chunk = chunk[nextLine+1:]
-				if !yield(line) {
-					return
-				}
-			}
-
-			// Copy the remainder to the start of the buffer for the next read
-			next = copy(buf, remainder)
-
-			// If we reached EOF, break out of the loop
-			if err == io.EOF {
-				break
-			}
-		}
+	// The station must be followed by the semicolon and cannot be empty
+	if nRow < 1 || row[nRow] != ';' {
+		return nil, 0, false
 	}
+
+	return row[:nRow], temp, true
 }
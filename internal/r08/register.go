@@ -0,0 +1,13 @@
+package eight
+
+import obrc "github.com/tyleryarnell/1brc"
+
+func init() {
+	obrc.Register(obrc.Implementation{
+		Version:     8,
+		Name:        "r08",
+		Description: "parallel file chunking",
+		Tags:        []string{"bytes", "int", "hashtable", "parallel", "mmap", "lenient"},
+		Calculator:  obrc.ContextFunc(CalculateContext),
+	})
+}
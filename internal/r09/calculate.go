@@ -0,0 +1,108 @@
+package nine
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"syscall"
+
+	obrc "github.com/tyleryarnell/1brc"
+	"github.com/tyleryarnell/1brc/internal/lines"
+	"github.com/tyleryarnell/1brc/internal/lphash"
+	"github.com/tyleryarnell/1brc/internal/worksteal"
+)
+
+// Calculate reads the input and calculates the min, average, and max values for each station
+func Calculate(inputFile string) (obrc.Results, error) {
+	return CalculateContext(context.Background(), inputFile)
+}
+
+// CalculateContext cuts the memory-mapped file into many small line-aligned segments that
+// one worker per core pulls from a shared cursor until none are left, so a worker that is
+// slowed down by page faults or a dense region of the file processes fewer segments instead
+// of holding up the others: see worksteal.Run. Each worker aggregates its segments into its
+// own hash table with processSegment, and the tables are merged at the end.
+func CalculateContext(ctx context.Context, inputFile string) (obrc.Results, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Open the file to be processed
+	file, err := os.Open(inputFile)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	// Get file size
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	fileSize := fileInfo.Size()
+	if fileSize == 0 {
+		// Nothing to map
+		return obrc.Results{}, nil
+	}
+
+	// Memory map the file
+	data, err := syscall.Mmap(int(file.Fd()), 0, int(fileSize), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, fmt.Errorf("failed to memory-map the file: %v", err)
+	}
+	defer syscall.Munmap(data)
+
+	newTable := func() *lphash.Table[lphash.Stats] {
+		return lphash.New[lphash.Stats](lphash.Config{})
+	}
+	return worksteal.Run(ctx, lines.TrimBOM(data), newTable, processSegment)
+}
+
+// processSegment aggregates every line of a segment into table.
+func processSegment(segment []byte, table *lphash.Table[lphash.Stats]) error {
+	for len(segment) > 0 {
+		var row []byte
+		row, segment = lines.Next(segment)
+		station, value, ok := parseRow(row)
+		if !ok {
+			return obrc.MalformedRowError(row)
+		}
+		s, _ := table.Upsert(station)
+		s.Add(value)
+	}
+	return nil
+}
+
+// parseRow splits a row into station and temperature in tenths of a degree, parsing the
+// temperature backwards from the end of the row. Temperatures are between -99.9 and 99.9
+// and always have one fractional digit. It returns false if the row cannot be parsed.
+func parseRow(row []byte) ([]byte, int32, bool) {
+	// The shortest row is "X;0.0", and the dot must be surrounded by digits
+	if len(row) < 5 || row[len(row)-2] != '.' || row[len(row)-1]-'0' > 9 || row[len(row)-3]-'0' > 9 {
+		return nil, 0, false
+	}
+	nRow := len(row) - 1 // last index
+	temp := int32(row[nRow] - '0')
+
+	nRow -= 2 // skip the last digit and the dot
+	temp = temp + int32(row[nRow]-'0')*10
+
+	nRow--
+
+	if row[nRow] >= '0' && row[nRow] <= '9' {
+		temp = temp + int32(row[nRow]-'0')*100
+		nRow--
+	}
+
+	if row[nRow] == '-' {
+		temp = -temp
+		nRow--
+	}
+
+	// The station must be followed by the semicolon and cannot be empty
+	if nRow < 1 || row[nRow] != ';' {
+		return nil, 0, false
+	}
+
+	return row[:nRow], temp, true
+}
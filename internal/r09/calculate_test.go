@@ -0,0 +1,29 @@
+package nine
+
+import "testing"
+
+func Test_parseRow(t *testing.T) {
+	tests := []struct {
+		row         string
+		wantStation string
+		wantTemp    int32
+		wantOK      bool
+	}{
+		{"Bamako;36.9", "Bamako", 369, true},
+		{"Ulaanbaatar;-1.3", "Ulaanbaatar", -13, true},
+		{"X;-99.9", "X", -999, true},
+		{"Oslo;0.0", "Oslo", 0, true},
+		{"", "", 0, false},
+		{";1.0", "", 0, false},
+		{"Oslo 1.0", "", 0, false},
+		{"Oslo;-", "", 0, false},
+		{"Oslo;1.a", "", 0, false},
+		{"Oslo;123.4", "", 0, false},
+	}
+	for _, tt := range tests {
+		station, temp, ok := parseRow([]byte(tt.row))
+		if string(station) != tt.wantStation || temp != tt.wantTemp || ok != tt.wantOK {
+			t.Errorf("parseRow(%q) = %q, %d, %v; want %q, %d, %v", tt.row, station, temp, ok, tt.wantStation, tt.wantTemp, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,13 @@
+package nine
+
+import obrc "github.com/tyleryarnell/1brc"
+
+func init() {
+	obrc.Register(obrc.Implementation{
+		Version:     9,
+		Name:        "r09",
+		Description: "work-stealing segments",
+		Tags:        []string{"bytes", "int", "hashtable", "parallel", "mmap", "work-stealing", "lenient"},
+		Calculator:  obrc.ContextFunc(CalculateContext),
+	})
+}
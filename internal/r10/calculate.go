@@ -0,0 +1,144 @@
+package ten
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"syscall"
+
+	obrc "github.com/tyleryarnell/1brc"
+	"github.com/tyleryarnell/1brc/internal/keyhash"
+	"github.com/tyleryarnell/1brc/internal/lines"
+	"github.com/tyleryarnell/1brc/internal/lphash"
+	"github.com/tyleryarnell/1brc/internal/swar"
+	"github.com/tyleryarnell/1brc/internal/worksteal"
+)
+
+// Rows that start at least tailSize bytes before the end of a segment are scanned in place:
+// the longest valid row (a 100 byte station, ';', "-99.9" and "\r\n") plus the word loads
+// that overrun it still fit, and so do the loads scanRows makes before it rejects a
+// malformed row. The rest of the segment is scanned in a copy.
+const tailSize = 128
+
+// Calculate reads the input and calculates the min, average, and max values for each station
+func Calculate(inputFile string) (obrc.Results, error) {
+	return CalculateContext(context.Background(), inputFile)
+}
+
+// CalculateContext schedules the memory-mapped file like r09 with worksteal.Run, cutting it
+// into small line-aligned segments that one worker per core pulls from a shared cursor, but
+// scans each row only once: see scanRows.
+func CalculateContext(ctx context.Context, inputFile string) (obrc.Results, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Open the file to be processed
+	file, err := os.Open(inputFile)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	// Get file size
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	fileSize := fileInfo.Size()
+	if fileSize == 0 {
+		// Nothing to map
+		return obrc.Results{}, nil
+	}
+
+	// Memory map the file
+	data, err := syscall.Mmap(int(file.Fd()), 0, int(fileSize), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, fmt.Errorf("failed to memory-map the file: %v", err)
+	}
+	defer syscall.Munmap(data)
+
+	// scanRows computes the station hashes itself, the tables use the same function so
+	// that their lookups agree with them
+	newTable := func() *lphash.Table[lphash.Stats] {
+		return lphash.New[lphash.Stats](lphash.Config{Hash: keyhash.Words})
+	}
+	return worksteal.Run(ctx, lines.TrimBOM(data), newTable, processSegment)
+}
+
+// processSegment aggregates every line of a segment into table.
+func processSegment(segment []byte, table *lphash.Table[lphash.Stats]) error {
+	n, err := scanRows(segment, len(segment)-tailSize, table)
+	if err != nil || n >= len(segment) {
+		return err
+	}
+
+	// Copy the last rows into a buffer with room for the word loads past their end, and
+	// terminate the last line of the file if it has no trailing newline
+	var tail [2 * tailSize]byte
+	rest := copy(tail[:], segment[n:])
+	if tail[rest-1] != '\n' {
+		tail[rest] = '\n'
+		rest++
+	}
+	_, err = scanRows(tail[:], rest, table)
+	return err
+}
+
+// scanRows aggregates the rows of buf that start before limit into table and returns the
+// offset after the last of them. It makes a single forward pass over each row: it loads the
+// station eight bytes at a time, searching each word for ';' and mixing it into the station
+// hash, then decodes the temperature from the word after ';' and skips the "\n" or "\r\n".
+// Every row must be complete, and buf must extend tailSize bytes past the start of the last
+// row. A row whose station is empty, too long or ends at a newline rather than ';', or whose
+// temperature is not of the form -?d?d.d followed by the line terminator, is returned as an
+// error.
+func scanRows(buf []byte, limit int, table *lphash.Table[lphash.Stats]) (int, error) {
+	semicolons, newlines := swar.Broadcast(';'), swar.Broadcast('\n')
+	pos := 0
+	for pos < limit {
+		start := pos
+		hash := uint64(keyhash.WordsSeed)
+		for {
+			word := swar.Load(buf, pos)
+			// The first match is exact, so a newline before the ';' is found too
+			if m := swar.Match(word, semicolons) | swar.Match(word, newlines); m != 0 {
+				i := swar.FirstIndex(m)
+				// Only the station bytes before ';' take part in the hash
+				hash = keyhash.MixWord(hash, word&(uint64(1)<<(8*i)-1))
+				pos += i
+				break
+			}
+			hash = keyhash.MixWord(hash, word)
+			pos += 8
+			if pos-start > obrc.MaxStationNameLength {
+				return start, malformedRow(buf, start)
+			}
+		}
+		if buf[pos] != ';' || pos == start || pos-start > obrc.MaxStationNameLength {
+			return start, malformedRow(buf, start)
+		}
+		station := buf[start:pos]
+
+		// Temperatures are between -99.9 and 99.9 and always have one fractional digit
+		temp, length, ok := swar.ParseTemperature(swar.Load(buf, pos+1))
+		pos += 1 + length // skip the semicolon and the temperature
+		if buf[pos] == '\r' {
+			pos++
+		}
+		if !ok || buf[pos] != '\n' {
+			return start, malformedRow(buf, start)
+		}
+		pos++ // skip the newline
+
+		s, _ := table.UpsertHash(station, hash)
+		s.Add(temp)
+	}
+	return pos, nil
+}
+
+// malformedRow returns the error for the row of buf that starts at start.
+func malformedRow(buf []byte, start int) error {
+	row, _ := lines.Next(buf[start:])
+	return obrc.MalformedRowError(row)
+}
@@ -0,0 +1,83 @@
+package ten
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	obrc "github.com/tyleryarnell/1brc"
+	"github.com/tyleryarnell/1brc/internal/keyhash"
+	"github.com/tyleryarnell/1brc/internal/lphash"
+)
+
+func TestProcessSegment(t *testing.T) {
+	// Stations of every length up to the maximum, so that ';' falls at every offset of a
+	// word, with temperatures of every shape
+	temps := []string{"0.0", "-0.1", "5.5", "-5.5", "12.3", "-12.3", "99.9", "-99.9"}
+	tempValues := []int32{0, -1, 55, -55, 123, -123, 999, -999}
+	var input strings.Builder
+	want := map[string]lphash.Stats{}
+	for length := 1; length <= 100; length++ {
+		station := strings.Repeat(string(rune('a'+length%26)), length)
+		for i, temp := range temps {
+			fmt.Fprintf(&input, "%s;%s\n", station, temp)
+			s := want[station]
+			s.Add(tempValues[i])
+			want[station] = s
+		}
+	}
+
+	for _, trailingNewline := range []bool{true, false} {
+		segment := input.String()
+		if !trailingNewline {
+			segment = strings.TrimSuffix(segment, "\n")
+		}
+		table := lphash.New[lphash.Stats](lphash.Config{Hash: keyhash.Words})
+		if err := processSegment([]byte(segment), table); err != nil {
+			t.Fatalf("trailing newline %v: %v", trailingNewline, err)
+		}
+
+		if table.Len() != len(want) {
+			t.Errorf("trailing newline %v: table has %d stations, want %d", trailingNewline, table.Len(), len(want))
+		}
+		for station, w := range want {
+			// Get hashes with keyhash.Words, so it only finds stations hashed the same way by scanRows
+			if got := table.Get([]byte(station)); got == nil || *got != w {
+				t.Errorf("trailing newline %v: %s = %+v, want %+v", trailingNewline, station, got, w)
+			}
+		}
+	}
+}
+
+func TestProcessShortSegment(t *testing.T) {
+	for _, segment := range []string{"A;1.0", "A;1.0\n", "A;1.0\nB;-2.5", "Bulawayo;-8.9\n"} {
+		table := lphash.New[lphash.Stats](lphash.Config{Hash: keyhash.Words})
+		if err := processSegment([]byte(segment), table); err != nil {
+			t.Errorf("processSegment(%q) error = %v", segment, err)
+		}
+		if n := strings.Count(strings.TrimSuffix(segment, "\n"), "\n") + 1; table.Len() != n {
+			t.Errorf("processSegment(%q) found %d stations, want %d", segment, table.Len(), n)
+		}
+	}
+}
+
+func TestProcessSegmentMalformed(t *testing.T) {
+	// Enough valid rows that the malformed row is scanned in place at the start of the
+	// segment and in the tail copy at its end
+	valid := strings.Repeat("Hamburg;12.0\n", 20)
+	rows := []string{"", "Foo", "Foo;", ";1.0", "Foo;1.0x", "Foo;1.0\r\r",
+		"Foo;abc", "Foo;+1.0", "Foo;1x.5", "Foo;900.5", "Foo;;1.0",
+		strings.Repeat("x", 101) + ";1.0", strings.Repeat("x", 300)}
+	for _, row := range rows {
+		for _, segment := range []string{row + "\n" + valid, valid + row + "\n", valid + row} {
+			if segment == valid {
+				continue
+			}
+			table := lphash.New[lphash.Stats](lphash.Config{Hash: keyhash.Words})
+			if err := processSegment([]byte(segment), table); !errors.Is(err, obrc.ErrMalformedRow) {
+				t.Errorf("processSegment(%.40q...) error = %v, want %v", segment, err, obrc.ErrMalformedRow)
+			}
+		}
+	}
+}
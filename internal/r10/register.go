@@ -0,0 +1,13 @@
+package ten
+
+import obrc "github.com/tyleryarnell/1brc"
+
+func init() {
+	obrc.Register(obrc.Implementation{
+		Version:     10,
+		Name:        "r10",
+		Description: "fused scan-hash-parse loop",
+		Tags:        []string{"bytes", "int", "hashtable", "parallel", "mmap", "work-stealing", "swar", "lenient"},
+		Calculator:  obrc.ContextFunc(CalculateContext),
+	})
+}
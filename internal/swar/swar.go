@@ -0,0 +1,100 @@
+// Package swar implements byte searches and parsing that process eight bytes at a time in
+// a uint64 (SIMD within a register). Words are loaded little endian, so the first byte of
+// the input is the least significant byte of the word.
+package swar
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+const (
+	lows  = 0x0101010101010101
+	highs = 0x8080808080808080
+)
+
+// Broadcast returns a word with every byte set to b.
+func Broadcast(b byte) uint64 {
+	return lows * uint64(b)
+}
+
+// Match returns a mask with the high bit set in each byte of word that equals the byte
+// repeated in pattern, see Broadcast. Only the lowest set bit is exact: a borrow can also
+// set the bits of bytes above the first match, so use FirstIndex rather than counting bits.
+func Match(word, pattern uint64) uint64 {
+	x := word ^ pattern
+	return (x - lows) &^ x & highs
+}
+
+// FirstIndex returns the index of the first matching byte in a mask returned by Match,
+// or 8 if there is none.
+func FirstIndex(mask uint64) int {
+	return bits.TrailingZeros64(mask) >> 3
+}
+
+// Load returns the eight bytes of data starting at i as a little endian word.
+func Load(data []byte, i int) uint64 {
+	return binary.LittleEndian.Uint64(data[i:])
+}
+
+// IndexByte returns the index of the first b in data, or -1 if there is none.
+func IndexByte(data []byte, b byte) int {
+	pattern := Broadcast(b)
+	i := 0
+	for ; i+8 <= len(data); i += 8 {
+		if m := Match(Load(data, i), pattern); m != 0 {
+			return i + FirstIndex(m)
+		}
+	}
+	for ; i < len(data); i++ {
+		if data[i] == b {
+			return i
+		}
+	}
+	return -1
+}
+
+// ParseTemperature decodes a temperature of the form -?d?d.d from the start of word, the
+// little endian load of the field and whatever follows it, without branching on the data.
+// It returns the temperature in tenths of a degree, the length of the field, and whether
+// the word starts with a field of that form at all. If ok is false the temperature is
+// unspecified, but the length is still 3 to 5. Callers check the byte after the field.
+func ParseTemperature(word uint64) (temp int32, length int, ok bool) {
+	// Digits (0x30-0x39) have bit 4 set, '.' (0x2e) does not. The '.' is the second,
+	// third or fourth byte, so dot is the bit index 12, 20 or 28 of its bit 4. Bit 28 is
+	// forced so that garbage without a '.' cannot shift by more than the word.
+	dot := bits.TrailingZeros64(^word&0x10101000 | 1<<28)
+
+	// All ones if the first byte is '-' (0x2d), which also has bit 4 clear, zero otherwise
+	sign := int64(^word<<59) >> 63
+
+	// Drop the sign, then shift the digits so that the tenths land in the fifth byte, the
+	// ones in the third and the tens, or zero if there are none, in the second byte
+	digits := ((word &^ uint64(sign&0xff)) << (28 - dot)) & 0x0f000f0f00
+
+	// The multiply lines up tens*100, ones*10 and tenths at bit 32 and sums them there,
+	// the other partial products stay below bit 32 or land above bit 41
+	abs := int64((digits * (100<<24 | 10<<16 | 1)) >> 32 & 0x3ff)
+
+	// The field is valid if every byte of it other than the sign and the dot is a digit,
+	// the byte at dot is '.', a first byte with bit 4 clear is '-', and one or two digits
+	// come before the dot. Each check leaves bad non-zero if it fails.
+	dotByte := dot &^ 7 // bit index of the byte holding the '.'
+	field := uint64(1)<<(dotByte+16) - 1
+	digitLanes := highs & field &^ (0x80 << dotByte) &^ (uint64(sign) & 0x80)
+	bad := nonDigits(word) & digitLanes
+	bad |= (word>>dotByte)&0xff ^ '.'
+	bad |= (word&0xff ^ '-') & uint64(sign)
+	bad |= uint64(dotByte>>3+int(sign)-1) >> 1 // integer digits, minus one: 0 or 1
+
+	return int32((abs ^ sign) - sign), dotByte>>3 + 2, bad == 0
+}
+
+// nonDigits returns a mask with the high bit set in each byte of word that is not an ASCII
+// digit. Unlike Match every bit is exact, no byte carries into the next.
+func nonDigits(word uint64) uint64 {
+	x := word ^ Broadcast('0') // digits become 0 to 9
+	high := (x & 0xf0f0f0f0f0f0f0f0) >> 1
+	low := ((x & 0x0f0f0f0f0f0f0f0f) + Broadcast(6)) & 0x1010101010101010 // low nibble above 9
+	return ((high | low) + Broadcast(0x7f)) & highs
+}
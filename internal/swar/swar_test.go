@@ -0,0 +1,163 @@
+package swar
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	obrc "github.com/tyleryarnell/1brc"
+)
+
+func TestMatchFirstIndex(t *testing.T) {
+	// Every target byte at every position, surrounded by every other byte value
+	for target := range 256 {
+		pattern := Broadcast(byte(target))
+		for pos := range 8 {
+			for other := range 256 {
+				if other == target {
+					continue
+				}
+				var word [8]byte
+				for i := range word {
+					word[i] = byte(other)
+				}
+				word[pos] = byte(target)
+				if got := FirstIndex(Match(Load(word[:], 0), pattern)); got != pos {
+					t.Fatalf("FirstIndex(Match(%x, %#x)) = %d, want %d", word, target, got, pos)
+				}
+			}
+		}
+		var none [8]byte
+		for i := range none {
+			none[i] = byte(target + 1)
+		}
+		if m := Match(Load(none[:], 0), pattern); m != 0 || FirstIndex(m) != 8 {
+			t.Fatalf("Match(%x, %#x) = %#x, want no match", none, target, m)
+		}
+	}
+}
+
+func TestIndexByte(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for range 10_000 {
+		data := make([]byte, rng.Intn(40))
+		for i := range data {
+			// Few distinct values, so that matches and near misses are common
+			data[i] = byte(rng.Intn(4)) + ';' - 1
+		}
+		if got, want := IndexByte(data, ';'), bytes.IndexByte(data, ';'); got != want {
+			t.Fatalf("IndexByte(%q, ';') = %d, want %d", data, got, want)
+		}
+	}
+}
+
+func BenchmarkIndexByte(b *testing.B) {
+	line := []byte("Some Long Station Name With Spaces;-12.3\n")
+	for range b.N {
+		IndexByte(line, ';')
+	}
+}
+
+// formatTemperature formats tenths of a degree the way measurement files do.
+func formatTemperature(temp int) string {
+	sign := ""
+	if temp < 0 {
+		sign, temp = "-", -temp
+	}
+	return fmt.Sprintf("%s%d.%d", sign, temp/10, temp%10)
+}
+
+// loadField loads field followed by rest, zero padded to a word.
+func loadField(field string, rest []byte) uint64 {
+	var buf [8]byte
+	copy(buf[copy(buf[:], field):], rest)
+	return Load(buf[:], 0)
+}
+
+func TestParseTemperature(t *testing.T) {
+	// Every valid temperature, followed by a newline and by each kind of byte that can follow
+	// a field in a buffer: the next row, padding, or anything else
+	rests := [][]byte{[]byte("\nHamburg"), []byte("\n"), {'\n', 0xff, 0xff, 0xff}, []byte("\r\n-99.9")}
+	for temp := -999; temp <= 999; temp++ {
+		field := formatTemperature(temp)
+		for _, rest := range rests {
+			got, length, ok := ParseTemperature(loadField(field, rest))
+			if int(got) != temp || length != len(field) || !ok {
+				t.Fatalf("ParseTemperature(%q + %q) = %d, %d, %t; want %d, %d, true", field, rest, got, length, ok, temp, len(field))
+			}
+		}
+	}
+
+	// A negative zero is zero
+	if got, length, ok := ParseTemperature(loadField("-0.0", []byte("\n"))); got != 0 || length != 4 || !ok {
+		t.Errorf("ParseTemperature(-0.0) = %d, %d, %t; want 0, 4, true", got, length, ok)
+	}
+}
+
+// checkAgainstValidateRow checks that ParseTemperature accepts the word loaded from b, a
+// field followed by a newline, exactly if obrc.ValidateRow accepts a row with that field, and
+// that it then parses the same temperature.
+func checkAgainstValidateRow(t *testing.T, b []byte) {
+	t.Helper()
+	var buf [8]byte
+	copy(buf[:], b)
+	got, length, ok := ParseTemperature(Load(buf[:], 0))
+	if length < 3 || length > 5 {
+		t.Fatalf("ParseTemperature(%q) length = %d, want 3 to 5", buf, length)
+	}
+	ok = ok && buf[length] == '\n'
+
+	want, wantOK := int32(0), false
+	if i := bytes.IndexByte(buf[:], '\n'); i >= 0 {
+		_, temp, err := obrc.ValidateRow(append([]byte("X;"), buf[:i]...))
+		want, wantOK = temp, err == nil
+	}
+	if ok != wantOK || ok && got != want {
+		t.Fatalf("ParseTemperature(%q) = %d, %d, ok %t; want %d, ok %t", buf, got, length, ok, want, wantOK)
+	}
+}
+
+func TestParseTemperatureGarbage(t *testing.T) {
+	// Every word of six bytes from the bytes of a field, their neighbours and a newline
+	alphabet := []byte("-+.,0159/:\na")
+	n := len(alphabet)
+	var b [6]byte
+	for i := range n * n * n * n * n * n {
+		for j, k := 0, i; j < len(b); j, k = j+1, k/n {
+			b[j] = alphabet[k%n]
+		}
+		checkAgainstValidateRow(t, b[:])
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	for range 1 << 16 {
+		checkAgainstValidateRow(t, binary.LittleEndian.AppendUint64(nil, rng.Uint64()))
+	}
+}
+
+func FuzzParseTemperature(f *testing.F) {
+	f.Add([]byte("0.0\n"))
+	f.Add([]byte("-99.9\nA"))
+	f.Add([]byte("12.3\r\n"))
+	f.Add([]byte("+1.0\n"))
+	f.Add([]byte("900.5\n"))
+	f.Add([]byte("1x.5\n"))
+	f.Fuzz(func(t *testing.T, b []byte) {
+		checkAgainstValidateRow(t, b)
+	})
+}
+
+func BenchmarkParseTemperature(b *testing.B) {
+	words := make([]uint64, 1024)
+	for i := range words {
+		words[i] = loadField(formatTemperature(i*7%1999-999), []byte("\n"))
+	}
+	var sum int32
+	for i := range b.N {
+		temp, _, _ := ParseTemperature(words[i&(len(words)-1)])
+		sum += temp
+	}
+	_ = sum
+}
@@ -0,0 +1,18 @@
+// Package versions links every Calculator implementation into the binary.
+// Importing it for side effects registers all versions with the obrc registry;
+// a new version only needs to be added to the import list below.
+package versions
+
+import (
+	_ "github.com/tyleryarnell/1brc/internal/baseline"
+	_ "github.com/tyleryarnell/1brc/internal/r01"
+	_ "github.com/tyleryarnell/1brc/internal/r02"
+	_ "github.com/tyleryarnell/1brc/internal/r03"
+	_ "github.com/tyleryarnell/1brc/internal/r04"
+	_ "github.com/tyleryarnell/1brc/internal/r05"
+	_ "github.com/tyleryarnell/1brc/internal/r06"
+	_ "github.com/tyleryarnell/1brc/internal/r07"
+	_ "github.com/tyleryarnell/1brc/internal/r08"
+	_ "github.com/tyleryarnell/1brc/internal/r09"
+	_ "github.com/tyleryarnell/1brc/internal/r10"
+)
@@ -0,0 +1,438 @@
+package versions
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	obrc "github.com/tyleryarnell/1brc"
+)
+
+type dataset struct {
+	name string
+	data string
+	// procs pins GOMAXPROCS while the dataset is processed, 0 leaves it unchanged
+	procs int
+}
+
+// randomMeasurements returns rows generated the same way as obrc.WriteMeasurements,
+// drawing stations from the first nStations entries of obrc.Stations.
+func randomMeasurements(seed int64, rows, nStations int) string {
+	return measurementsOf(obrc.Stations[:nStations], seed, rows)
+}
+
+// measurementsOf returns the rows obrc.WriteMeasurements generates for the given stations
+// with obrc.DefaultDistribution.
+func measurementsOf(stations []obrc.WeatherStation, seed int64, rows int) string {
+	var sb strings.Builder
+	if err := obrc.WriteMeasurementsTo(&sb, rows, stations, obrc.DefaultDistribution, seed, 1); err != nil {
+		panic(err)
+	}
+	return sb.String()
+}
+
+// rowsOfSize returns valid rows totalling exactly size bytes, size must be at least 13.
+func rowsOfSize(size int) string {
+	const row = "Hamburg;12.0\n"
+
+	var sb strings.Builder
+	for size >= 2*len(row) {
+		sb.WriteString(row)
+		size -= len(row)
+	}
+	// Pad the station name of the last row to hit the exact size
+	sb.WriteString(strings.Repeat("x", size-len(";1.0\n")) + ";1.0\n")
+	return sb.String()
+}
+
+// newlineAtChunkBoundary returns a dataset where splitting the file into procs equal
+// chunks of size/procs bytes puts a newline character offset bytes after the first
+// chunk boundary.
+func newlineAtChunkBoundary(procs, offset int) dataset {
+	head := strings.Repeat("Hamburg;12.0\n", 50)
+	boundary := len(head) - 1 - offset
+	tail := rowsOfSize(procs*boundary - len(head))
+	return dataset{
+		name:  fmt.Sprintf("newline at chunk boundary/%d procs/offset %d", procs, offset),
+		data:  head + tail,
+		procs: procs,
+	}
+}
+
+func datasets() []dataset {
+	sets := []dataset{
+		{name: "empty", data: ""},
+		{name: "single line", data: "Hamburg;12.0\n"},
+		{name: "single line without trailing newline", data: "Hamburg;12.0"},
+		{name: "one station", data: randomMeasurements(1, 1000, 1)},
+		{name: "negative zero", data: "Oslo;-0.0\nOslo;-0.1\nOslo;0.0\n"},
+		{name: "spec range", data: "Extreme;-99.9\nExtreme;99.9\nExtreme;0.0\n"},
+		{name: "rounding boundaries", data: "A;1.0\nA;1.1\nB;-1.0\nB;-1.1\nC;-0.1\nC;0.0\n"},
+		{name: "small", data: randomMeasurements(2, 10, 5)},
+		{name: "all stations", data: randomMeasurements(3, 50_000, len(obrc.Stations))},
+	}
+
+	synthetic := obrc.SyntheticStations(10_000, obrc.UniformNameLengths(1, 100), rand.New(rand.NewSource(5)))
+	sets = append(sets, dataset{name: "10k synthetic stations", data: measurementsOf(synthetic, 5, 50_000)})
+
+	// More stations than fit the fixed size tables the hash table versions used to have
+	synthetic = obrc.SyntheticStations(70_000, obrc.UniformNameLengths(1, 20), rand.New(rand.NewSource(8)))
+	sets = append(sets, dataset{name: "70k synthetic stations", data: measurementsOf(synthetic, 8, 100_000)})
+
+	noNewline := randomMeasurements(4, 5000, 50)
+	sets = append(sets, dataset{name: "missing trailing newline", data: strings.TrimSuffix(noNewline, "\n")})
+
+	// Line framing variants, see package lines. Large enough that r10 scans most rows in place
+	crlf := strings.ReplaceAll(randomMeasurements(9, 5000, 50), "\n", "\r\n")
+	sets = append(sets,
+		dataset{name: "crlf line endings", data: crlf},
+		dataset{name: "crlf without trailing newline", data: strings.TrimSuffix(crlf, "\r\n")},
+		dataset{name: "byte order mark", data: "\xef\xbb\xbf" + randomMeasurements(10, 5000, 50)},
+	)
+
+	for _, procs := range []int{2, 3, 4, 7} {
+		sets = append(sets, newlineAtChunkBoundary(procs, 0), newlineAtChunkBoundary(procs, 1))
+	}
+
+	for procs := 1; procs <= 9; procs += 4 {
+		sets = append(sets, dataset{
+			name:  fmt.Sprintf("many chunks/%d procs", procs),
+			data:  randomMeasurements(int64(procs), 20_000, 100),
+			procs: procs,
+		})
+	}
+	return sets
+}
+
+// streams returns readers over data that split it in awkward places,
+// like pipes and decompressors do.
+func streams(data string) map[string]io.Reader {
+	readers := map[string]io.Reader{
+		"half reads with data and EOF together": iotest.DataErrReader(iotest.HalfReader(strings.NewReader(data))),
+	}
+	if len(data) < 10_000 {
+		readers["one byte reads"] = iotest.OneByteReader(strings.NewReader(data))
+	}
+
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	zw.Write([]byte(data))
+	zw.Close()
+	zr, err := gzip.NewReader(&compressed)
+	if err != nil {
+		panic(err)
+	}
+	readers["gzip decompressor"] = zr
+	return readers
+}
+
+// TestImplementationsMatchBaseline runs every registered implementation on the same
+// datasets and requires the output to be identical to the baseline implementation.
+func TestImplementationsMatchBaseline(t *testing.T) {
+	baseline, ok := obrc.Lookup("baseline")
+	if !ok {
+		t.Fatal("baseline implementation is not registered")
+	}
+
+	dir := t.TempDir()
+	for i, ds := range datasets() {
+		t.Run(ds.name, func(t *testing.T) {
+			fileName := filepath.Join(dir, fmt.Sprintf("measurements.%d.txt", i))
+			if err := os.WriteFile(fileName, []byte(ds.data), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			if ds.procs > 0 {
+				defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(ds.procs))
+			}
+
+			want, err := baseline.Calculator.Calculate(fileName)
+			if err != nil {
+				t.Fatalf("baseline: %v", err)
+			}
+
+			for _, impl := range obrc.Implementations() {
+				if impl.Name == baseline.Name {
+					continue
+				}
+				got, err := impl.Calculator.Calculate(fileName)
+				if err != nil {
+					t.Errorf("%s: %v", impl.Name, err)
+					continue
+				}
+				if diff := obrc.Compare(want.Records(), got.Records()); !diff.OK() {
+					t.Errorf("%s differs from baseline:\n%v", impl.Name, diff)
+					continue
+				}
+				if got.String() != want.String() {
+					t.Errorf("%s output = %.200s, want %.200s", impl.Name, got, want)
+				}
+
+				sc, ok := impl.Calculator.(obrc.StreamCalculator)
+				if !ok {
+					continue
+				}
+				for name, r := range streams(ds.data) {
+					got, err := sc.CalculateReader(r)
+					if err != nil {
+						t.Errorf("%s CalculateReader(%s): %v", impl.Name, name, err)
+						continue
+					}
+					if got.String() != want.String() {
+						t.Errorf("%s CalculateReader(%s) = %.200s, want %.200s", impl.Name, name, got, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+// cancelAfter is a reader that cancels its context once n bytes have been read,
+// so the cancellation lands in the middle of a line.
+type cancelAfter struct {
+	r      io.Reader
+	n      int
+	cancel context.CancelFunc
+}
+
+func (c *cancelAfter) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p[:min(len(p), 1024)])
+	if c.n -= n; c.n <= 0 {
+		c.cancel()
+	}
+	return n, err
+}
+
+// TestImplementationsStopWhenCancelled requires every implementation to report
+// cancellation as an error, both before it starts and in the middle of a stream.
+func TestImplementationsStopWhenCancelled(t *testing.T) {
+	data := randomMeasurements(6, 20_000, 100)
+	fileName := filepath.Join(t.TempDir(), "measurements.txt")
+	if err := os.WriteFile(fileName, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, impl := range obrc.Implementations() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := obrc.CalculateContext(ctx, impl.Calculator, fileName); !errors.Is(err, context.Canceled) {
+			t.Errorf("%s: CalculateContext with cancelled context = %v, want %v", impl.Name, err, context.Canceled)
+		}
+
+		sc, ok := impl.Calculator.(obrc.StreamCalculator)
+		if !ok {
+			continue
+		}
+		ctx, cancel = context.WithCancel(context.Background())
+		r := &cancelAfter{r: strings.NewReader(data), n: len(data) / 2, cancel: cancel}
+		if _, err := obrc.CalculateReaderContext(ctx, sc, r); !errors.Is(err, context.Canceled) {
+			t.Errorf("%s: CalculateReaderContext cancelled mid-stream = %v, want %v", impl.Name, err, context.Canceled)
+		}
+	}
+}
+
+// TestImplementationsMatchBaselineOnFiles splits a dataset into several files, one of them
+// empty, and requires the merged results of every implementation to match the baseline on
+// the whole dataset.
+func TestImplementationsMatchBaselineOnFiles(t *testing.T) {
+	baseline, ok := obrc.Lookup("baseline")
+	if !ok {
+		t.Fatal("baseline implementation is not registered")
+	}
+
+	dir := t.TempDir()
+	data := randomMeasurements(7, 30_000, 200)
+	whole := filepath.Join(dir, "whole.txt")
+	if err := os.WriteFile(whole, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	want, err := baseline.Calculator.Calculate(whole)
+	if err != nil {
+		t.Fatalf("baseline: %v", err)
+	}
+
+	parts := filepath.Join(dir, "parts")
+	if err := os.Mkdir(parts, 0755); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.SplitAfter(data, "\n")
+	for i, n := 0, 0; n < len(lines); i++ {
+		// Parts of 0, 1000, 2000, ... lines
+		end := min(n+i*1000, len(lines))
+		part := filepath.Join(parts, fmt.Sprintf("part%02d.txt", i))
+		if err := os.WriteFile(part, []byte(strings.Join(lines[n:end], "")), 0644); err != nil {
+			t.Fatal(err)
+		}
+		n = end
+	}
+
+	files, err := obrc.ExpandInputs(filepath.Join(parts, "*.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, impl := range obrc.Implementations() {
+		got, fileResults, err := obrc.CalculateFiles(context.Background(), files, 3, func(ctx context.Context, fileName string) (obrc.Results, error) {
+			return obrc.CalculateContext(ctx, impl.Calculator, fileName)
+		})
+		if err != nil {
+			t.Errorf("%s: %v", impl.Name, err)
+			continue
+		}
+		if got.String() != want.String() {
+			t.Errorf("%s merged output = %.200s, want %.200s", impl.Name, got, want)
+		}
+		if rows := fileResults[1].Rows(); rows != 1000 {
+			t.Errorf("%s: %s has %d rows, want 1000", impl.Name, fileResults[1].File, rows)
+		}
+	}
+}
+
+// TestImplementationsSkipMalformedRows runs every streaming implementation on input with
+// malformed rows through a lenient ValidatingReader, and every lenient one on the file with
+// WithSkipMalformed. Both must give the results of the valid rows alone.
+func TestImplementationsSkipMalformedRows(t *testing.T) {
+	// Several chunks and segments for the parallel implementations
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+
+	baseline, ok := obrc.Lookup("baseline")
+	if !ok {
+		t.Fatal("baseline implementation is not registered")
+	}
+
+	clean := randomMeasurements(11, 5_000, 100)
+	want, err := baseline.Calculator.(obrc.StreamCalculator).CalculateReader(strings.NewReader(clean))
+	if err != nil {
+		t.Fatalf("baseline: %v", err)
+	}
+
+	malformed := []string{"Foo;abc\n", ";1.0\n", "Foo;1.25\n", "Foo;100.0\n", "Foo\n", "\n", "Foo;1;2.0\n", strings.Repeat("x", 101) + ";1.0\n"}
+	var dirty strings.Builder
+	var inserted int64
+	for i, line := range strings.SplitAfter(clean, "\n") {
+		dirty.WriteString(line)
+		if i%100 == 0 {
+			dirty.WriteString(malformed[(i/100)%len(malformed)])
+			inserted++
+		}
+	}
+
+	fileName := filepath.Join(t.TempDir(), "measurements.txt")
+	if err := os.WriteFile(fileName, []byte(dirty.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, impl := range obrc.Implementations() {
+		var got obrc.Results
+		var skipped int64
+		if sc, ok := impl.Calculator.(obrc.StreamCalculator); ok {
+			// Stream implementations read through a lenient ValidatingReader
+			vr := obrc.NewValidatingReader(strings.NewReader(dirty.String()), obrc.Lenient)
+			got, err = sc.CalculateReader(vr)
+			skipped = vr.Skipped()
+		} else if impl.HasTag("lenient") {
+			// The others skip the rows themselves when the context asks them to
+			ctx := obrc.WithSkipMalformed(context.Background(), func(n int64) { skipped += n })
+			got, err = obrc.CalculateContext(ctx, impl.Calculator, fileName)
+		} else {
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", impl.Name, err)
+			continue
+		}
+		if got.String() != want.String() {
+			t.Errorf("%s output = %.200s, want %.200s", impl.Name, got, want)
+		}
+		if skipped != inserted {
+			t.Errorf("%s: skipped %d rows, want %d", impl.Name, skipped, inserted)
+		}
+	}
+}
+
+// TestImplementationsRejectMalformedRows requires every implementation to fail with
+// ErrMalformedRow rather than give results or panic when a file holds a row it cannot parse.
+func TestImplementationsRejectMalformedRows(t *testing.T) {
+	clean := randomMeasurements(13, 20_000, 100)
+	half := strings.Index(clean[len(clean)/2:], "\n") + len(clean)/2 + 1
+
+	// Rows that no implementation can parse, at the start, in the middle and at the end
+	// with and without a trailing newline
+	inputs := map[string]string{}
+	rows := []string{"\n", "Hamburg\n", "Hamburg;\n", "Foo;abc\n", "Foo;+1.0\n", "Foo;1x.5\n", "Foo;900.5\n"}
+	for _, row := range rows {
+		inputs[fmt.Sprintf("%q first", row)] = row + clean
+		inputs[fmt.Sprintf("%q in the middle", row)] = clean[:half] + row + clean[half:]
+		inputs[fmt.Sprintf("%q last", row)] = clean + row
+		if row != "\n" {
+			inputs[fmt.Sprintf("%q last without newline", row)] = clean + strings.TrimSuffix(row, "\n")
+		}
+	}
+
+	fileName := filepath.Join(t.TempDir(), "measurements.txt")
+	for name, input := range inputs {
+		if err := os.WriteFile(fileName, []byte(input), 0644); err != nil {
+			t.Fatal(err)
+		}
+		for _, impl := range obrc.Implementations() {
+			if _, err := obrc.CalculateContext(context.Background(), impl.Calculator, fileName); !errors.Is(err, obrc.ErrMalformedRow) {
+				t.Errorf("%s with %s: error = %v, want %v", impl.Name, name, err, obrc.ErrMalformedRow)
+			}
+		}
+	}
+}
+
+// TestImplementationsOnFramingFixtures runs every implementation on the files in
+// testdata/framing, which hold the same rows with different line endings, with and without
+// a byte order mark and a trailing newline. All of them must give the expected results.
+func TestImplementationsOnFramingFixtures(t *testing.T) {
+	want, err := os.ReadFile(filepath.Join("testdata", "framing.expected.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := obrc.ExpandInputs(filepath.Join("testdata", "framing"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, impl := range obrc.Implementations() {
+			got, err := impl.Calculator.Calculate(file)
+			if err != nil {
+				t.Errorf("%s on %s: %v", impl.Name, file, err)
+				continue
+			}
+			if got.String()+"\n" != string(want) {
+				t.Errorf("%s on %s = %s, want %s", impl.Name, file, got, want)
+			}
+
+			sc, ok := impl.Calculator.(obrc.StreamCalculator)
+			if !ok {
+				continue
+			}
+			for name, r := range streams(string(data)) {
+				got, err := sc.CalculateReader(obrc.NewValidatingReader(r, obrc.Strict))
+				if err != nil {
+					t.Errorf("%s CalculateReader(%s, %s): %v", impl.Name, file, name, err)
+					continue
+				}
+				if got.String()+"\n" != string(want) {
+					t.Errorf("%s CalculateReader(%s, %s) = %s, want %s", impl.Name, file, name, got, want)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,132 @@
+// Package worksteal schedules the line-aligned segments of an input over one worker per
+// core, each aggregating into its own hash table, and merges the tables into results.
+package worksteal
+
+import (
+	"context"
+	"iter"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	obrc "github.com/tyleryarnell/1brc"
+	"github.com/tyleryarnell/1brc/internal/chunk"
+	"github.com/tyleryarnell/1brc/internal/lines"
+	"github.com/tyleryarnell/1brc/internal/lphash"
+	"github.com/tyleryarnell/1brc/internal/merge"
+)
+
+const (
+	segmentSize          = 4 * 1024 * 1024 // Target size of a segment
+	minSegmentsPerWorker = 4               // Small inputs are still cut into enough segments to balance
+)
+
+// Run cuts input into many small line-aligned segments that one worker per core pulls from
+// a shared cursor until none are left, so a worker that is slowed down by page faults or a
+// dense region of the input processes fewer segments instead of holding up the others.
+// Each worker aggregates its segments into its own table from newTable with process, and
+// the tables are merged at the end. Worker statistics are passed to obrc.ReportWorkerStats.
+//
+// The first error of process stops all workers and is returned instead of partial results.
+// If ctx asks to skip malformed rows, see obrc.WithSkipMalformed, the rows are validated
+// with obrc.ValidateRow and aggregated with the table's Upsert instead of process.
+func Run(ctx context.Context, input []byte, newTable func() *lphash.Table[lphash.Stats], process func(segment []byte, table *lphash.Table[lphash.Stats]) error) (obrc.Results, error) {
+	numWorkers := runtime.GOMAXPROCS(0)
+	numSegments := max((len(input)+segmentSize-1)/segmentSize, numWorkers*minSegmentsPerWorker)
+	segments := chunk.Plan(input, numSegments)
+
+	// Index of the next segment to process, shared by all workers
+	var cursor atomic.Int64
+
+	// A malformed row in one segment stops all workers, unless ctx asks to skip them
+	workCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	report, skipMalformed := obrc.SkipMalformed(ctx)
+	skipped := make([]int64, numWorkers)
+
+	tables := make([]*lphash.Table[lphash.Stats], numWorkers)
+	workerStats := make([]obrc.WorkerStats, numWorkers)
+	finished := make([]time.Time, numWorkers)
+
+	wg := sync.WaitGroup{}
+	for w := range numWorkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			table := newTable()
+			st := obrc.WorkerStats{Worker: w}
+			start := time.Now()
+
+			for workCtx.Err() == nil {
+				i := cursor.Add(1) - 1
+				if i >= int64(len(segments)) {
+					break
+				}
+				seg := segments[i]
+				if skipMalformed {
+					skipped[w] += processLenient(input[seg.Start:seg.End], table)
+				} else if err := process(input[seg.Start:seg.End], table); err != nil {
+					cancel(err)
+					break
+				}
+				st.Segments++
+				st.Bytes += seg.Len()
+			}
+
+			finished[w] = time.Now()
+			st.Busy = finished[w].Sub(start)
+			tables[w], workerStats[w] = table, st
+		}()
+	}
+	wg.Wait()
+
+	// Report cancellation or the first error instead of partial results
+	if err := context.Cause(workCtx); err != nil {
+		return nil, err
+	}
+
+	// Workers that ran out of segments early waited for the last one to finish
+	var last time.Time
+	for _, t := range finished {
+		if t.After(last) {
+			last = t
+		}
+	}
+	for w := range workerStats {
+		workerStats[w].Idle = last.Sub(finished[w])
+	}
+	obrc.ReportWorkerStats(ctx, workerStats)
+	if skipMalformed {
+		var total int64
+		for _, n := range skipped {
+			total += n
+		}
+		report(total)
+	}
+
+	// Merge the per-worker tables
+	entries := make([]iter.Seq[merge.Entry], len(tables))
+	for w, table := range tables {
+		entries[w] = merge.FromTable(table)
+	}
+	return merge.Tables(entries, numWorkers), nil
+}
+
+// processLenient aggregates the lines of a segment that pass obrc.ValidateRow into table,
+// and returns the number of malformed lines it skipped.
+func processLenient(segment []byte, table *lphash.Table[lphash.Stats]) int64 {
+	var skipped int64
+	for len(segment) > 0 {
+		var row []byte
+		row, segment = lines.Next(segment)
+		station, value, err := obrc.ValidateRow(row)
+		if err != nil {
+			skipped++
+			continue
+		}
+		s, _ := table.Upsert(station)
+		s.Add(value)
+	}
+	return skipped
+}
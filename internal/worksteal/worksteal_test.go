@@ -0,0 +1,93 @@
+package worksteal
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+
+	obrc "github.com/tyleryarnell/1brc"
+	"github.com/tyleryarnell/1brc/internal/lines"
+	"github.com/tyleryarnell/1brc/internal/lphash"
+)
+
+func newTable() *lphash.Table[lphash.Stats] {
+	return lphash.New[lphash.Stats](lphash.Config{})
+}
+
+// processValid aggregates the rows of a segment with obrc.ValidateRow and fails at the
+// first malformed one.
+func processValid(segment []byte, table *lphash.Table[lphash.Stats]) error {
+	for len(segment) > 0 {
+		var row []byte
+		row, segment = lines.Next(segment)
+		station, value, err := obrc.ValidateRow(row)
+		if err != nil {
+			return obrc.MalformedRowError(row)
+		}
+		s, _ := table.Upsert(station)
+		s.Add(value)
+	}
+	return nil
+}
+
+func TestWorkerStats(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(3))
+
+	data := strings.Repeat("Hamburg;12.0\nBulawayo;-8.9\n", 5000)
+	var reports [][]obrc.WorkerStats
+	ctx := obrc.WithWorkerStats(context.Background(), func(stats []obrc.WorkerStats) {
+		reports = append(reports, stats)
+	})
+	results, err := Run(ctx, []byte(data), newTable, processValid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "{Bulawayo=-8.9/-8.9/-8.9, Hamburg=12.0/12.0/12.0}"; results.String() != want {
+		t.Errorf("Run() = %v, want %v", results, want)
+	}
+
+	if len(reports) != 1 || len(reports[0]) != 3 {
+		t.Fatalf("reported %v, want one report for 3 workers", reports)
+	}
+	var segments int
+	var bytes int64
+	for w, st := range reports[0] {
+		if st.Worker != w || st.Busy < 0 || st.Idle < 0 {
+			t.Errorf("worker %d stats = %+v", w, st)
+		}
+		segments += st.Segments
+		bytes += st.Bytes
+	}
+	if segments != 3*minSegmentsPerWorker || bytes != int64(len(data)) {
+		t.Errorf("workers processed %d segments of %d bytes, want %d segments of %d bytes",
+			segments, bytes, 3*minSegmentsPerWorker, len(data))
+	}
+}
+
+func TestRunMalformedRows(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(3))
+
+	valid := strings.Repeat("Hamburg;12.0\n", 5000)
+	data := []byte(valid + "Foo;abc\n" + valid)
+
+	// The first error of a worker stops the others and is returned
+	if _, err := Run(context.Background(), data, newTable, processValid); !errors.Is(err, obrc.ErrMalformedRow) {
+		t.Errorf("Run() error = %v, want %v", err, obrc.ErrMalformedRow)
+	}
+
+	// Unless the context asks to skip malformed rows, which are then counted
+	var skipped []int64
+	ctx := obrc.WithSkipMalformed(context.Background(), func(n int64) { skipped = append(skipped, n) })
+	results, err := Run(ctx, data, newTable, processValid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "{Hamburg=12.0/12.0/12.0}"; results.String() != want {
+		t.Errorf("Run() = %v, want %v", results, want)
+	}
+	if len(skipped) != 1 || skipped[0] != 1 {
+		t.Errorf("reported %v skipped rows, want one report of 1", skipped)
+	}
+}
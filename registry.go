@@ -0,0 +1,80 @@
+package obrc
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+// Implementation describes a registered Calculator.
+type Implementation struct {
+	Version     int      // Numeric version, e.g. 8 for r08
+	Name        string   // Short name, e.g. "r08"
+	Description string   // Human readable description of the approach
+	Tags        []string // Tags such as "parallel" or "mmap"
+	Calculator  Calculator
+}
+
+// HasTag reports whether the implementation is tagged with tag.
+func (impl Implementation) HasTag(tag string) bool {
+	return slices.Contains(impl.Tags, tag)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Implementation)
+)
+
+// Register makes an implementation available by name and version.
+// It panics if the name or version is already registered or the Calculator is nil.
+func Register(impl Implementation) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if impl.Calculator == nil {
+		panic("obrc: Register calculator is nil for " + impl.Name)
+	}
+	if _, dup := registry[impl.Name]; dup {
+		panic("obrc: Register called twice for implementation " + impl.Name)
+	}
+	for _, other := range registry {
+		if other.Version == impl.Version {
+			panic(fmt.Sprintf("obrc: version %d registered by both %s and %s", impl.Version, other.Name, impl.Name))
+		}
+	}
+	registry[impl.Name] = impl
+}
+
+// Lookup finds a registered implementation by name (e.g. "r08") or version number (e.g. "8").
+func Lookup(key string) (Implementation, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	if impl, ok := registry[key]; ok {
+		return impl, true
+	}
+	if version, err := strconv.Atoi(key); err == nil {
+		for _, impl := range registry {
+			if impl.Version == version {
+				return impl, true
+			}
+		}
+	}
+	return Implementation{}, false
+}
+
+// Implementations returns all registered implementations ordered by version.
+func Implementations() []Implementation {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	impls := make([]Implementation, 0, len(registry))
+	for _, impl := range registry {
+		impls = append(impls, impl)
+	}
+	slices.SortFunc(impls, func(a, b Implementation) int {
+		return a.Version - b.Version
+	})
+	return impls
+}
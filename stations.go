@@ -1,22 +1,11 @@
 package obrc
 
-import (
-	"math"
-	"math/rand"
-)
-
 // WeatherStation represents a weather station with an ID and a mean temperature.
 type WeatherStation struct {
 	ID              string
 	MeanTemperature float64
 }
 
-// Measurement generates a random measurement based on the mean temperature of the station.
-func (ws *WeatherStation) Measurement() float64 {
-	m := rand.NormFloat64()*10 + ws.MeanTemperature
-	return math.Round(m*10) / 10
-}
-
 // Stations is a list of weather stations with their respective mean temperatures.
 var Stations = []WeatherStation{
 	{"Abha", 18.0},
@@ -0,0 +1,118 @@
+package obrc
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxStationNameLength is the longest station name allowed by the 1BRC spec, in bytes.
+const MaxStationNameLength = 100
+
+// NameLengths draws the byte length of a synthetic station name.
+type NameLengths func(rng *rand.Rand) int
+
+// UniformNameLengths returns name lengths uniformly distributed in [lo, hi] bytes.
+func UniformNameLengths(lo, hi int) NameLengths {
+	lo, hi = clampNameLength(lo), clampNameLength(hi)
+	if hi < lo {
+		lo, hi = hi, lo
+	}
+	return func(rng *rand.Rand) int {
+		return lo + rng.Intn(hi-lo+1)
+	}
+}
+
+// NormalNameLengths returns normally distributed name lengths, clamped to [1, 100] bytes.
+func NormalNameLengths(mean, stddev float64) NameLengths {
+	return func(rng *rand.Rand) int {
+		return clampNameLength(int(math.Round(rng.NormFloat64()*stddev + mean)))
+	}
+}
+
+func clampNameLength(n int) int {
+	return max(1, min(n, MaxStationNameLength))
+}
+
+// ParseNameLengths parses a name length distribution of the form
+// "uniform:MIN-MAX" (e.g. "uniform:1-100") or "normal:MEAN,STDDEV" (e.g. "normal:16,8").
+func ParseNameLengths(spec string) (NameLengths, error) {
+	kind, args, _ := strings.Cut(spec, ":")
+	switch kind {
+	case "uniform":
+		lo, hi, ok := strings.Cut(args, "-")
+		from, err1 := strconv.Atoi(lo)
+		to, err2 := strconv.Atoi(hi)
+		if !ok || err1 != nil || err2 != nil {
+			return nil, fmt.Errorf("invalid uniform name lengths %q, want uniform:MIN-MAX", spec)
+		}
+		return UniformNameLengths(from, to), nil
+	case "normal":
+		m, s, ok := strings.Cut(args, ",")
+		mean, err1 := strconv.ParseFloat(m, 64)
+		stddev, err2 := strconv.ParseFloat(s, 64)
+		if !ok || err1 != nil || err2 != nil {
+			return nil, fmt.Errorf("invalid normal name lengths %q, want normal:MEAN,STDDEV", spec)
+		}
+		return NormalNameLengths(mean, stddev), nil
+	default:
+		return nil, fmt.Errorf("unknown name length distribution %q", spec)
+	}
+}
+
+// nameRunes are the characters synthetic names are built from, grouped by UTF-8 width
+// so that a name can always be completed to an exact byte length.
+var nameRunes = [utf8.UTFMax + 1][]rune{
+	1: []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"),
+	2: []rune("äéèíñöøüßçåÆÐÞαβγδλπωЖЗИКЛМНПФ"),
+	3: []rune("東京大阪北京上海首尔서울ฯกขคงあいうえおカキク"),
+	4: []rune("🌡🌧🌨🌩🌪🌫🌬🏔🏕🏖𝔄𝔅𝔇𝔈"),
+}
+
+// SyntheticStations returns n weather stations with unique, randomly generated UTF-8 names.
+// Name lengths in bytes are drawn from lengths and never exceed MaxStationNameLength.
+// Names never contain ';' or '\n', and mean temperatures are borrowed from Stations.
+func SyntheticStations(n int, lengths NameLengths, rng *rand.Rand) []WeatherStation {
+	stations := make([]WeatherStation, 0, n)
+	seen := make(map[string]bool, n)
+
+	var name []byte
+	for len(stations) < n {
+		length := lengths(rng)
+		for attempt := 0; ; attempt++ {
+			name = appendSyntheticName(name[:0], length, rng)
+			if !seen[string(name)] {
+				break
+			}
+			// Short lengths run out of unique names quickly, so grow the name
+			if attempt%8 == 7 && length < MaxStationNameLength {
+				length++
+			}
+		}
+
+		seen[string(name)] = true
+		stations = append(stations, WeatherStation{
+			ID:              string(name),
+			MeanTemperature: Stations[rng.Intn(len(Stations))].MeanTemperature,
+		})
+	}
+	return stations
+}
+
+// appendSyntheticName appends a random name of exactly length bytes to buf.
+func appendSyntheticName(buf []byte, length int, rng *rand.Rand) []byte {
+	for remaining := length; remaining > 0; {
+		// Mostly ASCII, like real station names, with a mix of wider characters
+		width := 1
+		if rng.Intn(4) == 0 {
+			width = 1 + rng.Intn(min(remaining, utf8.UTFMax))
+		}
+		runes := nameRunes[width]
+		buf = utf8.AppendRune(buf, runes[rng.Intn(len(runes))])
+		remaining -= width
+	}
+	return buf
+}
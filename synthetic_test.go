@@ -0,0 +1,68 @@
+package obrc
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestNameRunesWidth(t *testing.T) {
+	for width, runes := range nameRunes {
+		for _, r := range runes {
+			if utf8.RuneLen(r) != width {
+				t.Errorf("rune %q is %d bytes, listed as %d", r, utf8.RuneLen(r), width)
+			}
+		}
+	}
+}
+
+func TestSyntheticStations(t *testing.T) {
+	tests := []struct {
+		name     string
+		n        int
+		lengths  NameLengths
+		min, max int
+	}{
+		{name: "10k uniform", n: 10_000, lengths: UniformNameLengths(1, 100), min: 1, max: 100},
+		{name: "short names", n: 1000, lengths: UniformNameLengths(1, 1), min: 1, max: 100},
+		{name: "exact length", n: 500, lengths: UniformNameLengths(100, 100), min: 100, max: 100},
+		{name: "normal", n: 2000, lengths: NormalNameLengths(16, 50), min: 1, max: 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stations := SyntheticStations(tt.n, tt.lengths, rand.New(rand.NewSource(1)))
+			if len(stations) != tt.n {
+				t.Fatalf("got %d stations, want %d", len(stations), tt.n)
+			}
+
+			seen := make(map[string]bool, len(stations))
+			for _, ws := range stations {
+				name := ws.ID
+				if seen[name] {
+					t.Errorf("duplicate station name %q", name)
+				}
+				seen[name] = true
+				if len(name) < tt.min || len(name) > tt.max {
+					t.Errorf("station name %q is %d bytes, want %d-%d", name, len(name), tt.min, tt.max)
+				}
+				if !utf8.ValidString(name) || strings.ContainsAny(name, ";\n") {
+					t.Errorf("invalid station name %q", name)
+				}
+			}
+		})
+	}
+}
+
+func TestParseNameLengths(t *testing.T) {
+	for _, spec := range []string{"uniform:1-100", "uniform:5-5", "normal:16,8"} {
+		if _, err := ParseNameLengths(spec); err != nil {
+			t.Errorf("ParseNameLengths(%q) error = %v", spec, err)
+		}
+	}
+	for _, spec := range []string{"", "uniform", "uniform:1", "normal:16", "zipf:1"} {
+		if _, err := ParseNameLengths(spec); err == nil {
+			t.Errorf("ParseNameLengths(%q) expected an error", spec)
+		}
+	}
+}
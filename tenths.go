@@ -0,0 +1,45 @@
+package obrc
+
+import (
+	"math"
+	"strconv"
+)
+
+// Measurements are aggregated as integer tenths of a degree (e.g. -12.3 is -123),
+// which keeps min, max and sum exact. The helpers below implement the 1BRC output
+// rules on that representation: values are rounded half toward positive infinity
+// to one fractional digit, so 1.05 becomes 1.1 and -1.05 becomes -1.0.
+
+// ToTenths converts a measurement with at most one fractional digit to tenths.
+func ToTenths(v float64) int64 {
+	return int64(math.Round(v * 10))
+}
+
+// MeanTenths returns sum/count rounded half toward positive infinity, in tenths.
+// count must be positive.
+func MeanTenths(sum, count int64) int64 {
+	// floor((sum + count/2) / count), done in integers to avoid float ties
+	n := 2*sum + count
+	d := 2 * count
+	q := n / d
+	if n < 0 && n%d != 0 {
+		q--
+	}
+	return q
+}
+
+// AppendTenths appends t formatted with exactly one fractional digit, e.g. -123 as "-12.3".
+// Zero is always written as "0.0", never "-0.0".
+func AppendTenths(dst []byte, t int64) []byte {
+	if t < 0 {
+		dst = append(dst, '-')
+		t = -t
+	}
+	dst = strconv.AppendInt(dst, t/10, 10)
+	return append(dst, '.', byte('0'+t%10))
+}
+
+// FormatTenths returns t formatted with exactly one fractional digit.
+func FormatTenths(t int64) string {
+	return string(AppendTenths(make([]byte, 0, 8), t))
+}
@@ -0,0 +1,109 @@
+package obrc
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatTenths(t *testing.T) {
+	tests := []struct {
+		name string
+		t    int64
+		want string
+	}{
+		{name: "zero", t: 0, want: "0.0"},
+		{name: "positive fraction", t: 5, want: "0.5"},
+		{name: "negative fraction", t: -5, want: "-0.5"},
+		{name: "positive", t: 123, want: "12.3"},
+		{name: "negative", t: -123, want: "-12.3"},
+		{name: "whole", t: 10, want: "1.0"},
+		{name: "negative whole", t: -10, want: "-1.0"},
+		{name: "max", t: 999, want: "99.9"},
+		{name: "min", t: -999, want: "-99.9"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatTenths(tt.t); got != tt.want {
+				t.Errorf("FormatTenths(%d) = %q, want %q", tt.t, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToTenths(t *testing.T) {
+	tests := []struct {
+		name string
+		v    float64
+		want int64
+	}{
+		{name: "zero", v: 0, want: 0},
+		{name: "negative zero", v: -0.0, want: 0},
+		{name: "positive", v: 36.9, want: 369},
+		{name: "negative", v: -1.3, want: -13},
+		{name: "inexact binary fraction", v: 0.3, want: 3},
+		{name: "negative inexact binary fraction", v: -0.3, want: -3},
+		{name: "max", v: 99.9, want: 999},
+		{name: "min", v: -99.9, want: -999},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ToTenths(tt.v); got != tt.want {
+				t.Errorf("ToTenths(%v) = %d, want %d", tt.v, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMeanTenths(t *testing.T) {
+	tests := []struct {
+		name  string
+		sum   int64
+		count int64
+		want  string
+	}{
+		{name: "exact", sum: 369, count: 1, want: "36.9"},
+		{name: "half rounds up", sum: 10 + 11, count: 2, want: "1.1"},
+		{name: "negative half rounds toward positive infinity", sum: -10 - 11, count: 2, want: "-1.0"},
+		{name: "small positive half", sum: 1, count: 2, want: "0.1"},
+		{name: "small negative half is not negative zero", sum: -1, count: 2, want: "0.0"},
+		{name: "negative half above minus one", sum: -3, count: 2, want: "-0.1"},
+		{name: "below half rounds down", sum: 1, count: 3, want: "0.0"},
+		{name: "negative below half is not negative zero", sum: -1, count: 3, want: "0.0"},
+		{name: "above half rounds up", sum: 5, count: 3, want: "0.2"},
+		{name: "negative above half rounds down", sum: -5, count: 3, want: "-0.2"},
+		{name: "x5 boundary", sum: 125 + 124 + 126 + 125, count: 4, want: "12.5"},
+		{name: "x.x5 boundary", sum: 12 + 13, count: 2, want: "1.3"},
+		{name: "negative x.x5 boundary", sum: -12 - 13, count: 2, want: "-1.2"},
+		{name: "min", sum: -999 * 3, count: 3, want: "-99.9"},
+		{name: "max", sum: 999 * 3, count: 3, want: "99.9"},
+		{name: "large sum", sum: 999 * 1_000_000_000, count: 1_000_000_000, want: "99.9"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatTenths(MeanTenths(tt.sum, tt.count)); got != tt.want {
+				t.Errorf("MeanTenths(%d, %d) = %s, want %s", tt.sum, tt.count, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResultsWriteTo(t *testing.T) {
+	results := Results{
+		{Station: "Abha", Min: -230, Max: 592, Sum: -230 + 592, Count: 2},
+		{Station: "Zürich", Min: -1, Max: 0, Sum: -1, Count: 2},
+	}
+
+	var sb strings.Builder
+	n, err := results.WriteTo(&sb)
+	if err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+
+	want := "{Abha=-23.0/18.1/59.2, Zürich=-0.1/0.0/0.0}\n"
+	if got := sb.String(); got != want {
+		t.Errorf("WriteTo() = %q, want %q", got, want)
+	}
+	if n != int64(len(want)) {
+		t.Errorf("WriteTo() n = %d, want %d", n, len(want))
+	}
+}
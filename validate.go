@@ -0,0 +1,241 @@
+package obrc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/tyleryarnell/1brc/internal/lines"
+)
+
+// Reasons a row does not match the 1BRC grammar, station;temperature, wrapped in a *ParseError.
+var (
+	ErrSeparator        = errors.New("row must contain exactly one ';'")
+	ErrStationName      = fmt.Errorf("station name must be 1 to %d bytes", MaxStationNameLength)
+	ErrTemperature      = errors.New("temperature must be a number with one or two integer digits and exactly one fractional digit")
+	ErrTemperatureRange = fmt.Errorf("temperature must be between %.1f and %.1f", MinMeasurement, MaxMeasurement)
+)
+
+// ErrMalformedRow is returned by implementations for a row they cannot parse. They only check
+// as much of a row as their parser relies on, a ValidatingReader finds every malformed row.
+var ErrMalformedRow = errors.New("malformed row")
+
+// MalformedRowError returns the error of an implementation for row, wrapping ErrMalformedRow.
+func MalformedRowError(row []byte) error {
+	return fmt.Errorf("%w %.120q", ErrMalformedRow, row)
+}
+
+// Bounds of a valid temperature in tenths of a degree.
+const (
+	minTenths = int32(MinMeasurement * 10)
+	maxTenths = int32(MaxMeasurement * 10)
+)
+
+// ParseError reports a malformed row of a measurements file.
+type ParseError struct {
+	Line   int64  // Line number, starting at 1
+	Offset int64  // Byte offset of the start of the line
+	Row    string // The row without its line terminator, truncated if it is very long
+	Err    error  // Why the row is malformed, e.g. ErrTemperature
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d (byte offset %d): %v: %.120q", e.Line, e.Offset, e.Err, e.Row)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// ValidateRow checks a row without its line terminator against the 1BRC grammar: a station
+// name of 1 to MaxStationNameLength bytes, one ';', and a temperature between MinMeasurement
+// and MaxMeasurement of the form -?d?d.d. It returns the station and the temperature in
+// tenths of a degree, or one of the Err reasons above.
+func ValidateRow(row []byte) (station []byte, temp int32, err error) {
+	sep := bytes.IndexByte(row, ';')
+	if sep < 0 || bytes.IndexByte(row[sep+1:], ';') >= 0 {
+		return nil, 0, ErrSeparator
+	}
+	station = row[:sep]
+	if err := checkStationNameLength(len(station)); err != nil {
+		return nil, 0, err
+	}
+
+	field := row[sep+1:]
+	negative := len(field) > 0 && field[0] == '-'
+	if negative {
+		field = field[1:]
+	}
+	dot := bytes.IndexByte(field, '.')
+	if dot < 1 || dot != len(field)-2 {
+		return nil, 0, ErrTemperature
+	}
+	var tenths int32
+	for i, c := range field {
+		if i == dot {
+			continue
+		}
+		if c < '0' || c > '9' {
+			return nil, 0, ErrTemperature
+		}
+		// Stop accumulating once out of range, so that long numbers cannot overflow
+		if tenths <= max(maxTenths, -minTenths) {
+			tenths = tenths*10 + int32(c-'0')
+		}
+	}
+	if negative {
+		tenths = -tenths
+	}
+	if tenths < minTenths || tenths > maxTenths {
+		return nil, 0, ErrTemperatureRange
+	}
+	// In range but with more than two integer digits, e.g. 000.5
+	if dot > 2 {
+		return nil, 0, ErrTemperature
+	}
+	return station, tenths, nil
+}
+
+// IsTemperature reports whether field has the form -?d?d.d of a temperature that ValidateRow
+// accepts. Implementations that parse temperatures with strconv use it to reject the numbers
+// strconv accepts but measurement files never hold, e.g. +1.0, 900.5 or 1e2.
+func IsTemperature(field string) bool {
+	field = strings.TrimPrefix(field, "-")
+	dot := len(field) - 2
+	if dot < 1 || dot > 2 || field[dot] != '.' {
+		return false
+	}
+	for i := range len(field) {
+		if i != dot && (field[i] < '0' || field[i] > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// checkStationNameLength returns ErrStationName unless a station name of n bytes is allowed.
+func checkStationNameLength(n int) error {
+	if n < 1 || n > MaxStationNameLength {
+		return ErrStationName
+	}
+	return nil
+}
+
+// Validation selects what a ValidatingReader does with malformed rows.
+type Validation int
+
+const (
+	Strict  Validation = iota // Fail with a *ParseError at the first malformed row
+	Lenient                   // Skip malformed rows and count them
+)
+
+// ValidatingReader passes the valid rows of a measurements stream through unchanged and
+// either fails at or drops the malformed ones, so that any StreamCalculator can be run on
+// input it would otherwise mis-parse or crash on. Rows are framed like the implementations
+// frame them: a leading byte order mark is dropped and "\r\n" terminators are allowed.
+type ValidatingReader struct {
+	br         *bufio.Reader
+	validation Validation
+	line       int64  // Number of lines read
+	offset     int64  // Byte offset of the next line
+	skipped    int64  // Number of malformed rows dropped
+	pending    []byte // Rest of the current valid row, not yet returned by Read
+	err        error  // Error to return once pending is empty
+}
+
+// NewValidatingReader returns a reader that validates the rows of r with ValidateRow.
+func NewValidatingReader(r io.Reader, validation Validation) *ValidatingReader {
+	return &ValidatingReader{br: bufio.NewReaderSize(r, 64*1024), validation: validation}
+}
+
+// Skipped returns the number of malformed rows dropped in lenient mode so far.
+func (v *ValidatingReader) Skipped() int64 {
+	return v.skipped
+}
+
+// Read fills p with valid rows. In strict mode it returns a *ParseError once it reaches a
+// malformed row, after returning every row before it.
+func (v *ValidatingReader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(v.pending) == 0 {
+			if v.err != nil {
+				break
+			}
+			v.pending, v.err = v.nextRow()
+			continue
+		}
+		c := copy(p[n:], v.pending)
+		v.pending = v.pending[c:]
+		n += c
+	}
+	if n > 0 {
+		return n, nil
+	}
+	return 0, v.err
+}
+
+// nextRow returns the next valid row including its line terminator, along with the error
+// that ended the input if the row is the last one.
+func (v *ValidatingReader) nextRow() ([]byte, error) {
+	if v.line == 0 && v.offset == 0 {
+		// Drop a byte order mark, the first row starts after it
+		if peek, _ := v.br.Peek(len(lines.BOM)); bytes.Equal(peek, lines.BOM) {
+			n, _ := v.br.Discard(len(lines.BOM))
+			v.offset = int64(n)
+		}
+	}
+	for {
+		start := v.offset
+		line, err := v.br.ReadSlice('\n')
+		v.offset += int64(len(line))
+		if len(line) == 0 {
+			return nil, err
+		}
+		v.line++
+
+		row := bytes.TrimSuffix(line, []byte{'\n'})
+		if err == bufio.ErrBufferFull {
+			// The line does not fit the buffer, so it is far too long to be valid: validate
+			// what was read for the reason, then skip to the end of the line
+			row = bytes.Clone(row)
+			for err == bufio.ErrBufferFull {
+				line, err = v.br.ReadSlice('\n')
+				v.offset += int64(len(line))
+			}
+		}
+
+		row = lines.TrimCR(row)
+		_, _, verr := ValidateRow(row)
+		if verr == nil {
+			return line, err
+		}
+		if v.validation == Strict {
+			return nil, &ParseError{Line: v.line, Offset: start, Row: string(row), Err: verr}
+		}
+		v.skipped++
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+type skipMalformedKey struct{}
+
+// WithSkipMalformed returns a context that asks implementations which support it, those
+// tagged "lenient", to skip the rows that fail ValidateRow like a lenient ValidatingReader
+// would, and to pass the number of rows they skipped to report once a calculation has finished.
+func WithSkipMalformed(ctx context.Context, report func(skipped int64)) context.Context {
+	return context.WithValue(ctx, skipMalformedKey{}, report)
+}
+
+// SkipMalformed returns the report function of a context made by WithSkipMalformed, or
+// false if ctx does not ask to skip malformed rows.
+func SkipMalformed(ctx context.Context) (report func(skipped int64), ok bool) {
+	report, ok = ctx.Value(skipMalformedKey{}).(func(int64))
+	return report, ok
+}
@@ -0,0 +1,151 @@
+package obrc
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestValidateRow(t *testing.T) {
+	long := strings.Repeat("x", MaxStationNameLength)
+	tests := []struct {
+		row         string
+		wantStation string
+		wantTemp    int32
+		wantErr     error
+	}{
+		{"Hamburg;12.0", "Hamburg", 120, nil},
+		{"Bulawayo;-8.9", "Bulawayo", -89, nil},
+		{"X;99.9", "X", 999, nil},
+		{"X;-99.9", "X", -999, nil},
+		{"X;-0.0", "X", 0, nil},
+		{long + ";1.0", long, 10, nil},
+		{"São Paulo;25.5", "São Paulo", 255, nil},
+		{"", "", 0, ErrSeparator},
+		{"Hamburg", "", 0, ErrSeparator},
+		{"Ham;burg;1.0", "", 0, ErrSeparator},
+		{";1.0", "", 0, ErrStationName},
+		{long + "x;1.0", "", 0, ErrStationName},
+		{"Foo;abc", "", 0, ErrTemperature},
+		{"Foo;", "", 0, ErrTemperature},
+		{"Foo;12", "", 0, ErrTemperature},
+		{"Foo;1.25", "", 0, ErrTemperature},
+		{"Foo;.5", "", 0, ErrTemperature},
+		{"Foo;5.", "", 0, ErrTemperature},
+		{"Foo;+5.0", "", 0, ErrTemperature},
+		{"Foo;--5.0", "", 0, ErrTemperature},
+		{"Foo;1a.0", "", 0, ErrTemperature},
+		{"Foo;12.0\r", "", 0, ErrTemperature},
+		{"Foo;000.5", "", 0, ErrTemperature},
+		{"Foo;-012.3", "", 0, ErrTemperature},
+		{"X;09.5", "X", 95, nil},
+		{"Foo;100.0", "", 0, ErrTemperatureRange},
+		{"Foo;-100.0", "", 0, ErrTemperatureRange},
+		{"Foo;99999999999999999999.0", "", 0, ErrTemperatureRange},
+	}
+	for _, tt := range tests {
+		station, temp, err := ValidateRow([]byte(tt.row))
+		if string(station) != tt.wantStation || temp != tt.wantTemp || err != tt.wantErr {
+			t.Errorf("ValidateRow(%q) = %q, %d, %v; want %q, %d, %v", tt.row, station, temp, err, tt.wantStation, tt.wantTemp, tt.wantErr)
+		}
+	}
+}
+
+func TestIsTemperature(t *testing.T) {
+	tests := map[string]bool{
+		"0.0": true, "-0.0": true, "12.3": true, "-99.9": true, "09.5": true,
+		"": false, "-": false, "abc": false, "12": false, "1.25": false, ".5": false, "5.": false,
+		"+1.0": false, "--1.0": false, "1x.5": false, "900.5": false, "-012.3": false, "1e2": false,
+	}
+	for field, want := range tests {
+		if got := IsTemperature(field); got != want {
+			t.Errorf("IsTemperature(%q) = %t, want %t", field, got, want)
+		}
+	}
+}
+
+const mixedInput = "Hamburg;12.0\nFoo;abc\nBulawayo;-8.9\n;1.0\nOslo;1.0"
+
+func TestValidatingReaderStrict(t *testing.T) {
+	got, err := io.ReadAll(NewValidatingReader(strings.NewReader(mixedInput), Strict))
+	if string(got) != "Hamburg;12.0\n" {
+		t.Errorf("read %q before the error, want the rows before the malformed one", got)
+	}
+
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("ReadAll() error = %v, want a *ParseError", err)
+	}
+	if want := (ParseError{Line: 2, Offset: 13, Row: "Foo;abc", Err: ErrTemperature}); *perr != want {
+		t.Errorf("ParseError = %+v, want %+v", *perr, want)
+	}
+	if !errors.Is(err, ErrTemperature) {
+		t.Errorf("errors.Is(%v, ErrTemperature) = false", err)
+	}
+	if want := `line 2 (byte offset 13): temperature must be a number with one or two integer digits and exactly one fractional digit: "Foo;abc"`; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestValidatingReaderLenient(t *testing.T) {
+	// Small reads on both sides
+	vr := NewValidatingReader(iotest.HalfReader(strings.NewReader(mixedInput)), Lenient)
+	got, err := io.ReadAll(iotest.OneByteReader(vr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Hamburg;12.0\nBulawayo;-8.9\nOslo;1.0"; string(got) != want {
+		t.Errorf("read %q, want %q", got, want)
+	}
+	if vr.Skipped() != 2 {
+		t.Errorf("Skipped() = %d, want 2", vr.Skipped())
+	}
+}
+
+func TestValidatingReaderFraming(t *testing.T) {
+	input := "\xef\xbb\xbfHamburg;12.0\r\nFoo;1.25\r\nOslo;1.0\r"
+
+	got, err := io.ReadAll(NewValidatingReader(strings.NewReader(input), Lenient))
+	if err != nil || string(got) != "Hamburg;12.0\r\nOslo;1.0\r" {
+		t.Errorf("lenient read %q, %v; want the valid rows after the byte order mark", got, err)
+	}
+
+	_, err = io.ReadAll(NewValidatingReader(strings.NewReader(input), Strict))
+	var perr *ParseError
+	if !errors.As(err, &perr) || perr.Line != 2 || perr.Offset != 17 || perr.Row != "Foo;1.25" {
+		t.Errorf("strict read error = %#v, want a ParseError for line 2 at offset 17", err)
+	}
+
+	for _, input := range []string{"", "\xef\xbb\xbf"} {
+		if got, err := io.ReadAll(NewValidatingReader(strings.NewReader(input), Strict)); err != nil || len(got) != 0 {
+			t.Errorf("ReadAll(%q) = %q, %v; want no rows", input, got, err)
+		}
+	}
+}
+
+func TestValidatingReaderLongLine(t *testing.T) {
+	input := "Hamburg;12.0\n" + strings.Repeat("x", 200_000) + ";1.0\nOslo;1.0\n"
+
+	vr := NewValidatingReader(strings.NewReader(input), Lenient)
+	got, err := io.ReadAll(vr)
+	if err != nil || string(got) != "Hamburg;12.0\nOslo;1.0\n" || vr.Skipped() != 1 {
+		t.Errorf("lenient read %q, %v with %d skipped rows", got, err, vr.Skipped())
+	}
+
+	_, err = io.ReadAll(NewValidatingReader(strings.NewReader(input), Strict))
+	var perr *ParseError
+	if !errors.As(err, &perr) || perr.Line != 2 || perr.Offset != 13 || perr.Err != ErrSeparator {
+		t.Errorf("strict read error = %v, want a ParseError on line 2", err)
+	}
+}
+
+func TestValidatingReaderReadError(t *testing.T) {
+	readErr := errors.New("disk on fire")
+	r := io.MultiReader(strings.NewReader("Hamburg;12.0\n"), iotest.ErrReader(readErr))
+	got, err := io.ReadAll(NewValidatingReader(r, Strict))
+	if string(got) != "Hamburg;12.0\n" || err != readErr {
+		t.Errorf("ReadAll() = %q, %v; want the first row and %v", got, err, readErr)
+	}
+}
@@ -0,0 +1,145 @@
+package obrc
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Record is the printed min/mean/max of a station, in tenths of a degree.
+type Record struct {
+	Station        string
+	Min, Mean, Max int64
+}
+
+// Records returns the printed form of the results.
+func (rs Results) Records() []Record {
+	records := make([]Record, 0, len(rs))
+	for _, r := range rs {
+		records = append(records, Record{Station: r.Station, Min: r.Min, Mean: r.Mean(), Max: r.Max})
+	}
+	return records
+}
+
+// entryPattern matches one "Name=min/mean/max" entry followed by its separator.
+// The lazy name lets station names contain '=' or ", ".
+var entryPattern = regexp.MustCompile(`^(.+?)=(-?\d+\.\d)/(-?\d+\.\d)/(-?\d+\.\d)(, |$)`)
+
+// ParseRecords parses output in the canonical 1BRC format written by Results.WriteTo.
+func ParseRecords(r io.Reader) ([]Record, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	text := strings.TrimRight(string(data), "\r\n")
+	if !strings.HasPrefix(text, "{") || !strings.HasSuffix(text, "}") {
+		return nil, fmt.Errorf("results must be enclosed in braces")
+	}
+	text = text[1 : len(text)-1]
+
+	var records []Record
+	for text != "" {
+		m := entryPattern.FindStringSubmatch(text)
+		if m == nil {
+			return nil, fmt.Errorf("malformed entry after %d stations: %.40q", len(records), text)
+		}
+
+		var values [3]int64
+		for i, s := range m[2:5] {
+			v, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				return nil, fmt.Errorf("station %q: %v", m[1], err)
+			}
+			values[i] = ToTenths(v)
+		}
+		records = append(records, Record{Station: m[1], Min: values[0], Mean: values[1], Max: values[2]})
+		text = text[len(m[0]):]
+	}
+	return records, nil
+}
+
+// Mismatch describes a field that differs between expected and actual records of a station.
+type Mismatch struct {
+	Station          string
+	Field            string // "min", "mean" or "max"
+	Expected, Actual int64
+}
+
+// Diff is the semantic difference between expected and actual results.
+type Diff struct {
+	Missing    []string // Stations expected but not produced
+	Extra      []string // Stations produced but not expected
+	Mismatches []Mismatch
+}
+
+// OK reports whether the results matched.
+func (d Diff) OK() bool {
+	return len(d.Missing) == 0 && len(d.Extra) == 0 && len(d.Mismatches) == 0
+}
+
+// String returns a human readable report of the differences.
+func (d Diff) String() string {
+	if d.OK() {
+		return "results match"
+	}
+
+	var sb strings.Builder
+	for _, station := range d.Missing {
+		fmt.Fprintf(&sb, "missing station %q\n", station)
+	}
+	for _, station := range d.Extra {
+		fmt.Fprintf(&sb, "extra station %q\n", station)
+	}
+	for _, m := range d.Mismatches {
+		fmt.Fprintf(&sb, "station %q: %s expected %s, got %s\n",
+			m.Station, m.Field, FormatTenths(m.Expected), FormatTenths(m.Actual))
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// Compare reports the stations and fields that differ between expected and actual.
+func Compare(expected, actual []Record) Diff {
+	var d Diff
+
+	got := make(map[string]Record, len(actual))
+	for _, r := range actual {
+		got[r.Station] = r
+	}
+
+	seen := make(map[string]bool, len(expected))
+	for _, want := range expected {
+		seen[want.Station] = true
+		r, ok := got[want.Station]
+		if !ok {
+			d.Missing = append(d.Missing, want.Station)
+			continue
+		}
+		for _, f := range []struct {
+			name             string
+			expected, actual int64
+		}{
+			{"min", want.Min, r.Min},
+			{"mean", want.Mean, r.Mean},
+			{"max", want.Max, r.Max},
+		} {
+			if f.expected != f.actual {
+				d.Mismatches = append(d.Mismatches, Mismatch{
+					Station:  want.Station,
+					Field:    f.name,
+					Expected: f.expected,
+					Actual:   f.actual,
+				})
+			}
+		}
+	}
+
+	for _, r := range actual {
+		if !seen[r.Station] {
+			d.Extra = append(d.Extra, r.Station)
+		}
+	}
+	return d
+}
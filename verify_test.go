@@ -0,0 +1,56 @@
+package obrc
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseRecords(t *testing.T) {
+	results := Results{
+		{Station: "A=B", Min: -999, Max: 999, Sum: 0, Count: 2},
+		{Station: "Foo, Bar", Min: -5, Max: 5, Sum: 0, Count: 3},
+		{Station: "Zürich", Min: 93, Max: 93, Sum: 93, Count: 1},
+	}
+
+	got, err := ParseRecords(strings.NewReader(results.String() + "\n"))
+	if err != nil {
+		t.Fatalf("ParseRecords() error = %v", err)
+	}
+	if diff := Compare(results.Records(), got); !diff.OK() {
+		t.Errorf("ParseRecords() round trip differs:\n%v", diff)
+	}
+}
+
+func TestParseRecordsMalformed(t *testing.T) {
+	for _, input := range []string{"", "Abha=1.0/2.0/3.0", "{Abha=1.0/2.0}", "{Abha=1/2/3}"} {
+		if _, err := ParseRecords(strings.NewReader(input)); err == nil {
+			t.Errorf("ParseRecords(%q) expected an error", input)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	expected := []Record{
+		{Station: "Abha", Min: -230, Mean: 180, Max: 592},
+		{Station: "Accra", Min: -101, Mean: 264, Max: 664},
+	}
+	actual := []Record{
+		{Station: "Abha", Min: -230, Mean: 181, Max: 592},
+		{Station: "Zagreb", Min: 0, Mean: 107, Max: 300},
+	}
+
+	diff := Compare(expected, actual)
+	if diff.OK() {
+		t.Fatal("Compare() reported no differences")
+	}
+	if len(diff.Missing) != 1 || diff.Missing[0] != "Accra" {
+		t.Errorf("Compare() missing = %v, want [Accra]", diff.Missing)
+	}
+	if len(diff.Extra) != 1 || diff.Extra[0] != "Zagreb" {
+		t.Errorf("Compare() extra = %v, want [Zagreb]", diff.Extra)
+	}
+	want := Mismatch{Station: "Abha", Field: "mean", Expected: 180, Actual: 181}
+	if len(diff.Mismatches) != 1 || diff.Mismatches[0] != want {
+		t.Errorf("Compare() mismatches = %v, want [%v]", diff.Mismatches, want)
+	}
+}
@@ -0,0 +1,30 @@
+package obrc
+
+import (
+	"context"
+	"time"
+)
+
+// WorkerStats describes the work done by one worker goroutine of a parallel implementation.
+type WorkerStats struct {
+	Worker   int
+	Segments int           // Number of segments the worker processed
+	Bytes    int64         // Bytes in those segments
+	Busy     time.Duration // Time spent processing segments
+	Idle     time.Duration // Time between the worker running out of segments and the last worker finishing
+}
+
+type workerStatsKey struct{}
+
+// WithWorkerStats returns a context that asks implementations which support it to pass the
+// statistics of their workers to report once a calculation has finished.
+func WithWorkerStats(ctx context.Context, report func([]WorkerStats)) context.Context {
+	return context.WithValue(ctx, workerStatsKey{}, report)
+}
+
+// ReportWorkerStats passes stats to the report function of ctx, if any.
+func ReportWorkerStats(ctx context.Context, stats []WorkerStats) {
+	if report, ok := ctx.Value(workerStatsKey{}).(func([]WorkerStats)); ok {
+		report(stats)
+	}
+}
@@ -3,12 +3,15 @@ package obrc
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"math/rand"
 	"os"
 )
 
-// WriteMeasurements writes the measurements to the provided file and prints progress after every 10% of lines
-func WriteMeasurements(fileName string, size int) error {
+// WriteMeasurements writes measurements of the given stations, drawn from dist, to the provided file
+// and prints progress after every 100k lines.
+// All randomness is drawn from rng, so the same seed and size always produce a byte-identical file.
+func WriteMeasurements(fileName string, size int, stations []WeatherStation, dist Distribution, rng *rand.Rand) error {
 	file, err := os.Create(fileName)
 	if err != nil {
 		return fmt.Errorf("Failed to create file: %v", err)
@@ -18,16 +21,15 @@ func WriteMeasurements(fileName string, size int) error {
 	bw := bufio.NewWriter(file)
 	defer bw.Flush()
 
+	var line []byte
 	for i := 0; i < size; i++ {
 		if i%100000 == 0 {
 			fmt.Printf("Written %d lines\n",
 				i)
 		}
-		nStations := len(Stations)
-		station := Stations[rand.Intn(nStations)]
-		line := station.ID + ";" + fmt.Sprintf("%.1f", station.Measurement()) + "\n"
+		line = appendMeasurement(line[:0], stations, dist, i, rng)
 
-		if _, err := bw.WriteString(line); err != nil {
+		if _, err := bw.Write(line); err != nil {
 			return fmt.Errorf("Failed to write line %d: %v", i, err)
 		}
 	}
@@ -35,3 +37,85 @@ func WriteMeasurements(fileName string, size int) error {
 	fmt.Println("Done writing file")
 	return nil
 }
+
+// appendMeasurement appends a random "<station>;<temperature>\n" row to buf.
+func appendMeasurement(buf []byte, stations []WeatherStation, dist Distribution, row int, rng *rand.Rand) []byte {
+	station := &stations[dist.Station(rng, len(stations))]
+	buf = append(buf, station.ID...)
+	buf = append(buf, ';')
+	buf = AppendTenths(buf, ToTenths(dist.Measurement(rng, station, row)))
+	return append(buf, '\n')
+}
+
+// blockRows is the number of rows in a block of WriteMeasurementsParallel, about 1 MB of
+// rows with the default stations.
+const blockRows = 64 * 1024
+
+// WriteMeasurementsParallel writes size measurements of the given stations, drawn from dist,
+// to the provided file using workers goroutines.
+//
+// The rows are generated in blocks of blockRows rows. Block b is generated by worker
+// b%workers from its own generator seeded with seed+b, so the file is byte-identical for the
+// same seed and size whatever the number of workers, and its first block matches
+// WriteMeasurements with rand.NewSource(seed). The blocks are written in order as they are
+// done, every row is generated once and at most a few blocks per worker are held in memory.
+func WriteMeasurementsParallel(fileName string, size int, stations []WeatherStation, dist Distribution, seed int64, workers int) error {
+	file, err := os.Create(fileName)
+	if err != nil {
+		return fmt.Errorf("Failed to create file: %v", err)
+	}
+	defer file.Close()
+
+	if err := writeBlocks(file, size, stations, dist, seed, workers); err != nil {
+		return fmt.Errorf("Failed to write measurements: %v", err)
+	}
+
+	fmt.Println("Done writing file")
+	return file.Close()
+}
+
+// WriteMeasurementsTo streams the rows WriteMeasurementsParallel would write with the same
+// arguments to w. The workers generate the next blocks while w consumes the earlier ones,
+// e.g. while a compressor compresses them. It returns the first error of w.
+func WriteMeasurementsTo(w io.Writer, size int, stations []WeatherStation, dist Distribution, seed int64, workers int) error {
+	return writeBlocks(w, size, stations, dist, seed, workers)
+}
+
+// writeBlocks generates the blocks of WriteMeasurementsParallel with workers goroutines and
+// writes them to w in order. Each worker hands its blocks over through its own channel, the
+// writer takes them round robin, so a worker runs at most two blocks ahead of the writer.
+func writeBlocks(w io.Writer, size int, stations []WeatherStation, dist Distribution, seed int64, workers int) error {
+	numBlocks := (size + blockRows - 1) / blockRows
+	workers = max(1, min(workers, numBlocks))
+
+	blocks := make([]chan []byte, workers)
+	for w := range blocks {
+		blocks[w] = make(chan []byte, 2)
+	}
+	done := make(chan struct{})
+	defer close(done)
+
+	for w := range workers {
+		go func() {
+			for b := w; b < numBlocks; b += workers {
+				rng := rand.New(rand.NewSource(seed + int64(b)))
+				buf := make([]byte, 0, blockRows*16)
+				for row := b * blockRows; row < min((b+1)*blockRows, size); row++ {
+					buf = appendMeasurement(buf, stations, dist, row, rng)
+				}
+				select {
+				case blocks[w] <- buf:
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
+
+	for b := range numBlocks {
+		if _, err := w.Write(<-blocks[b%workers]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
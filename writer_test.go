@@ -0,0 +1,117 @@
+package obrc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteMeasurementsSeeded(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, seed int64) []byte {
+		fileName := filepath.Join(dir, name)
+		if err := WriteMeasurements(fileName, 1000, Stations, DefaultDistribution, rand.New(rand.NewSource(seed))); err != nil {
+			t.Fatalf("WriteMeasurements() error = %v", err)
+		}
+		data, err := os.ReadFile(fileName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	a, b, c := write("a.txt", 42), write("b.txt", 42), write("c.txt", 43)
+	if !bytes.Equal(a, b) {
+		t.Error("same seed produced different files")
+	}
+	if bytes.Equal(a, c) {
+		t.Error("different seeds produced identical files")
+	}
+}
+
+func TestWriteMeasurementsParallel(t *testing.T) {
+	dir := t.TempDir()
+	read := func(name string) []byte {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	// Enough rows for several blocks
+	const size = 4*blockRows + 100
+	if err := WriteMeasurements(filepath.Join(dir, "sequential.txt"), blockRows, Stations, DefaultDistribution, rand.New(rand.NewSource(7))); err != nil {
+		t.Fatal(err)
+	}
+	for _, workers := range []int{1, 3, 8} {
+		if err := WriteMeasurementsParallel(filepath.Join(dir, fmt.Sprint(workers)), size, Stations, DefaultDistribution, 7, workers); err != nil {
+			t.Fatalf("WriteMeasurementsParallel() error = %v", err)
+		}
+	}
+
+	one := read("1")
+	if !bytes.Equal(read("3"), one) || !bytes.Equal(read("8"), one) {
+		t.Error("the number of workers changed the file")
+	}
+	if n := bytes.Count(one, []byte{'\n'}); n != size {
+		t.Errorf("wrote %d rows, want %d", n, size)
+	}
+	if !bytes.HasPrefix(one, read("sequential.txt")) {
+		t.Error("the first block does not match WriteMeasurements with the same seed")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 4 {
+		t.Errorf("found %d files after writing 4", len(entries))
+	}
+}
+
+func TestWriteMeasurementsTo(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "parallel.txt")
+	// Enough rows for several blocks per worker
+	const size = 16*blockRows + 100
+	for _, workers := range []int{1, 3, 8} {
+		if err := WriteMeasurementsParallel(fileName, size, Stations, DefaultDistribution, 11, workers); err != nil {
+			t.Fatal(err)
+		}
+		want, err := os.ReadFile(fileName)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var buf bytes.Buffer
+		if err := WriteMeasurementsTo(&buf, size, Stations, DefaultDistribution, 11, workers); err != nil {
+			t.Fatalf("WriteMeasurementsTo() error = %v", err)
+		}
+		if !bytes.Equal(buf.Bytes(), want) {
+			t.Errorf("%d workers: WriteMeasurementsTo differs from WriteMeasurementsParallel", workers)
+		}
+	}
+}
+
+// failingWriter fails every write after the first n.
+type failingWriter struct {
+	n   int
+	err error
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.n == 0 {
+		return 0, w.err
+	}
+	w.n--
+	return len(p), nil
+}
+
+func TestWriteMeasurementsToWriteError(t *testing.T) {
+	// The write fails while the workers are still generating blocks, which must not keep
+	// WriteMeasurementsTo from returning
+	errFull := errors.New("disk full")
+	w := &failingWriter{n: 2, err: errFull}
+	if err := WriteMeasurementsTo(w, 16*blockRows, Stations, DefaultDistribution, 1, 4); err != errFull {
+		t.Errorf("WriteMeasurementsTo() error = %v, want %v", err, errFull)
+	}
+}